@@ -3,10 +3,12 @@ package codex
 import "github.com/bitrainforest/filmeta-hic/core/errno"
 
 var (
-	OK                 = errno.NewError(0, "success")
-	ErrService         = errno.NewError(50000, "Service err:[%v]")
-	ErrTokenInvalid    = errno.NewError(40001, "Token invalid")
-	ErrParamIllegal    = errno.NewError(40011, "Parameter is invalid:[%v]")
-	ErrUserAppExist    = errno.NewError(40012, "address  exist")
-	ErrUserAppNotExist = errno.NewError(40013, "address not exist")
+	OK                    = errno.NewError(0, "success")
+	ErrService            = errno.NewError(50000, "Service err:[%v]")
+	ErrTokenInvalid       = errno.NewError(40001, "Token invalid")
+	ErrParamIllegal       = errno.NewError(40011, "Parameter is invalid:[%v]")
+	ErrUserAppExist       = errno.NewError(40012, "address  exist")
+	ErrUserAppNotExist    = errno.NewError(40013, "address not exist")
+	ErrDeadLetterNotExist = errno.NewError(40014, "dead letter not exist")
+	ErrReplayJobNotExist  = errno.NewError(40015, "replay job not exist")
 )
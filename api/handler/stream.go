@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/pulsar/api/codex"
+	"github.com/bitrainforest/pulsar/api/req"
+	"github.com/bitrainforest/pulsar/internal/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamBatch how many notifications are read from the stream at most each time
+	streamBatch = 100
+	// streamBlock how long to wait for the new notifications before a ping is sent
+	streamBlock = 15 * time.Second
+	// streamTouch how often the app is marked as having a streaming client
+	streamTouch = time.Minute
+	// streamWriteWait the websocket client which can't receive a message in time is closed
+	streamWriteWait = 10 * time.Second
+)
+
+var (
+	// streamCursor the id of the redis stream entry, e.g. 1660000000000-0
+	streamCursor = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+	// the apps are authenticated by the token, the dashboards of any origin are allowed
+	upgrader = websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+)
+
+// Stream push the notifications of the app by the websocket, or by the server-sent events otherwise,
+// the clients resume after the cursor of the last notification they received
+func (userApp UserAppHandler) Stream(c *gin.Context) {
+	var (
+		param req.StreamReq
+	)
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		codex.ErrService.FormatErrMsg(err).RenderJson(c)
+		return
+	}
+	if err = c.ShouldBindQuery(&param); err != nil {
+		codex.ErrParamIllegal.FormatErrMsg(err).RenderJson(c)
+		return
+	}
+	cursor := param.Cursor
+	if cursor == "" {
+		cursor = c.GetHeader("Last-Event-ID")
+	}
+	if cursor == "" {
+		if cursor, err = userApp.UserAppService.LastStreamCursor(c, appId); err != nil {
+			codex.ErrService.FormatErrMsg(err).RenderJson(c)
+			return
+		}
+	}
+	if !streamCursor.MatchString(cursor) {
+		codex.ErrParamIllegal.FormatErrMsg("cursor is invalid").RenderJson(c)
+		return
+	}
+
+	// the notifications are appended to the stream only while the app has a streaming client
+	if err = userApp.UserAppService.TouchStream(c, appId); err != nil {
+		codex.ErrService.FormatErrMsg(err).RenderJson(c)
+		return
+	}
+	first, gap, err := userApp.UserAppService.StreamGap(c, appId, cursor)
+	if err != nil {
+		codex.ErrService.FormatErrMsg(err).RenderJson(c)
+		return
+	}
+	var reset *req.StreamReset
+	if gap {
+		reset = &req.StreamReset{Cursor: cursor, First: first}
+	}
+
+	// the request context of the http server times out, the stream lasts until the client is gone
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		err = userApp.streamWebSocket(ctx, cancel, c, appId, cursor, reset)
+	} else {
+		err = userApp.streamEvents(ctx, cancel, c, appId, cursor, reset)
+	}
+	if err != nil {
+		log.Infof("[Stream] appId:%v stream closed: %v", appId, err)
+	}
+}
+
+// readStream send the notifications after the cursor until the ctx is done or a send fails,
+// ping is called when there is no notification for a while
+func (userApp UserAppHandler) readStream(ctx context.Context, appId, cursor string,
+	send func(cache.StreamEvent) error, ping func() error) error {
+	touched := time.Now()
+	for ctx.Err() == nil {
+		// the client is still there, keep its notifications appended
+		if time.Since(touched) >= streamTouch {
+			if err := userApp.UserAppService.TouchStream(ctx, appId); err != nil {
+				log.Warnf("[Stream] appId:%v touch err:%v", appId, err)
+			}
+			touched = time.Now()
+		}
+		events, err := userApp.UserAppService.ReadStream(ctx, appId, cursor, streamBatch, streamBlock)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			if err = ping(); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, event := range events {
+			if err = send(event); err != nil {
+				return err
+			}
+			cursor = event.Cursor
+		}
+	}
+	return ctx.Err()
+}
+
+// streamEvents the reset is sent without an id, the Last-Event-ID of the client is kept
+func (userApp UserAppHandler) streamEvents(ctx context.Context, cancel context.CancelFunc,
+	c *gin.Context, appId, cursor string, reset *req.StreamReset) error {
+	w := c.Writer
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	if reset != nil {
+		data, err := json.Marshal(reset)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", req.StreamResetEvent, data); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+
+	gone := w.CloseNotify()
+	go func() {
+		select {
+		case <-gone:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return userApp.readStream(ctx, appId, cursor, func(event cache.StreamEvent) error {
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: notify\ndata: %s\n\n", event.Cursor, event.Payload); err != nil {
+			return err
+		}
+		w.Flush()
+		return nil
+	}, func() error {
+		if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+			return err
+		}
+		w.Flush()
+		return nil
+	})
+}
+
+func (userApp UserAppHandler) streamWebSocket(ctx context.Context, cancel context.CancelFunc,
+	c *gin.Context, appId, cursor string, reset *req.StreamReset) error {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if reset != nil {
+		payload, err := json.Marshal(reset)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(req.StreamEvent{Event: req.StreamResetEvent, Cursor: cursor, Payload: payload})
+		if err != nil {
+			return err
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return err
+		}
+	}
+	// the messages of the client are discarded, a read error means the client is gone
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	return userApp.readStream(ctx, appId, cursor, func(event cache.StreamEvent) error {
+		data, err := json.Marshal(req.StreamEvent{Cursor: event.Cursor, Payload: event.Payload})
+		if err != nil {
+			return err
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}, func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait))
+	})
+}
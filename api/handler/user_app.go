@@ -2,15 +2,22 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
+	"github.com/bitrainforest/pulsar/internal/service/subscriber"
 	"github.com/bitrainforest/pulsar/internal/service/subscriber/actoraddress"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
 
 	"github.com/bitrainforest/pulsar/api/middleware"
+	"github.com/bitrainforest/pulsar/internal/cache"
 	"github.com/pkg/errors"
 
 	"github.com/bitrainforest/filmeta-hic/core/httpx/response"
+	"github.com/bitrainforest/filmeta-hic/core/log"
 	"github.com/bitrainforest/pulsar/api/codex"
 	"github.com/bitrainforest/pulsar/api/req"
 	"github.com/bitrainforest/pulsar/internal/model"
@@ -41,6 +48,243 @@ func (userApp UserAppHandler) Apply(c *gin.Context) response.Response {
 	return codex.OK.WithData(resp)
 }
 
+// UpdateApp update the settings of the app
+func (userApp UserAppHandler) UpdateApp(c *gin.Context) response.Response {
+	var (
+		param req.UpdateAppReq
+	)
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if err = c.ShouldBind(&param); err != nil {
+		return codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	if err = userApp.UserAppService.UpdateConfirmations(c, appId, *param.Confirmations); err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	return codex.OK
+}
+
+func (userApp UserAppHandler) ListWebhooks(c *gin.Context) response.Response {
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	list, err := userApp.UserAppService.ListWebhooks(c, appId)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	resp := req.ListWebhookResp{Urls: make([]string, 0, len(list))}
+	for _, v := range list {
+		resp.Urls = append(resp.Urls, v.Url)
+	}
+	return codex.OK.WithData(resp)
+}
+
+// AddWebhook register an HTTPS endpoint the notifications of the app are posted to
+func (userApp UserAppHandler) AddWebhook(c *gin.Context) response.Response {
+	var (
+		param req.AddWebhookReq
+	)
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if err = c.ShouldBind(&param); err != nil {
+		return codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	list, err := userApp.UserAppService.ListWebhooks(c, appId)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	registered := false
+	for _, v := range list {
+		registered = registered || v.Url == param.Url
+	}
+	if !registered && len(list) >= model.MaxAppWebhooks {
+		return codex.ErrParamIllegal.FormatErrMsg(
+			fmt.Sprintf("an app has %d webhooks at most", model.MaxAppWebhooks))
+	}
+	signingSecret, err := userApp.UserAppService.AddWebhook(c, appId, param.Url)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	return codex.OK.WithData(req.AddWebhookResp{SigningSecret: signingSecret})
+}
+
+func (userApp UserAppHandler) CancelWebhook(c *gin.Context) response.Response {
+	var (
+		param req.CancelWebhookReq
+	)
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if err = c.ShouldBind(&param); err != nil {
+		return codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	if err = userApp.UserAppService.CancelWebhook(c, appId, param.Url); err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	return codex.OK
+}
+
+const defaultPageSize = 20
+
+func newDeadLetterItem(letter *model.DeadLetter) *req.DeadLetterItem {
+	return &req.DeadLetterItem{
+		Id:         letter.Id.Hex(),
+		Channel:    letter.Channel,
+		Url:        letter.Url,
+		Err:        letter.Err,
+		Attempts:   letter.Attempts,
+		Skipped:    len(letter.Payloads),
+		UpdateTime: letter.UpdateTime,
+		CreateTime: letter.CreateTime,
+	}
+}
+
+// ListDeadLetters the notifications of the app which are failed after all the retries, the latest first
+func (userApp UserAppHandler) ListDeadLetters(c *gin.Context) response.Response {
+	var (
+		param req.ListDeadLetterReq
+	)
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if err = c.ShouldBindQuery(&param); err != nil {
+		return codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	if param.Page == 0 {
+		param.Page = 1
+	}
+	if param.PageSize == 0 {
+		param.PageSize = defaultPageSize
+	}
+	list, total, err := userApp.UserAppService.ListDeadLetters(c, appId,
+		(param.Page-1)*param.PageSize, param.PageSize)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	resp := req.ListDeadLetterResp{Total: total, List: make([]*req.DeadLetterItem, 0, len(list))}
+	for _, v := range list {
+		resp.List = append(resp.List, newDeadLetterItem(v))
+	}
+	return codex.OK.WithData(resp)
+}
+
+func (userApp UserAppHandler) getDeadLetter(c *gin.Context) (model.DeadLetter, response.Response) {
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return model.DeadLetter{}, codex.ErrService.FormatErrMsg(err)
+	}
+	letter, err := userApp.UserAppService.GetDeadLetter(c, appId, c.Param("id"))
+	if err != nil {
+		return letter, codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	if letter.IsEmpty() {
+		return letter, codex.ErrDeadLetterNotExist
+	}
+	return letter, nil
+}
+
+// GetDeadLetter inspect the dead letter with its payload
+func (userApp UserAppHandler) GetDeadLetter(c *gin.Context) response.Response {
+	letter, respErr := userApp.getDeadLetter(c)
+	if respErr != nil {
+		return respErr
+	}
+	item := newDeadLetterItem(&letter)
+	item.Payload = json.RawMessage(letter.Payload)
+	for _, payload := range letter.Payloads {
+		item.Payloads = append(item.Payloads, json.RawMessage(payload))
+	}
+	return codex.OK.WithData(item)
+}
+
+// RedriveDeadLetter deliver the dead letter to the app again, it's removed once delivered
+func (userApp UserAppHandler) RedriveDeadLetter(c *gin.Context) response.Response {
+	letter, respErr := userApp.getDeadLetter(c)
+	if respErr != nil {
+		return respErr
+	}
+	if err := userApp.UserAppService.RedriveDeadLetter(c, letter); err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	return codex.OK
+}
+
+// Replay start a job which delivers the historical messages of the addresses to the app, tagged as replayed
+func (userApp UserAppHandler) Replay(c *gin.Context) response.Response {
+	var (
+		param req.ReplayReq
+	)
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if err = c.ShouldBind(&param); err != nil {
+		return codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	if param.ToHeight-param.FromHeight >= subscriber.MaxReplayEpochs {
+		return codex.ErrParamIllegal.FormatErrMsg(
+			fmt.Sprintf("the height range must be in %d epochs", subscriber.MaxReplayEpochs))
+	}
+	params := subscriber.ReplayParams{
+		AppId: appId,
+		From:  abi.ChainEpoch(param.FromHeight),
+		To:    abi.ChainEpoch(param.ToHeight),
+	}
+	for _, a := range param.Addresses {
+		addr, respErr := userApp.GetActorAddress(c, a)
+		if respErr != nil {
+			return respErr
+		}
+		params.Addresses = append(params.Addresses, addr)
+	}
+	job, err := subscriber.Sub.Replay(c, params)
+	if errors.Is(err, subscriber.ErrNotSubscribed) || errors.Is(err, subscriber.ErrReplayRunning) {
+		return codex.ErrParamIllegal.FormatErrMsg(err)
+	}
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	return codex.OK.WithData(newReplayJobResp(job))
+}
+
+// GetReplay the progress of the replay job of the app
+func (userApp UserAppHandler) GetReplay(c *gin.Context) response.Response {
+	appId, err := userApp.getAppId(c)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	job, ok, err := subscriber.Sub.ReplayJob(c, appId, c.Param("id"))
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if !ok {
+		return codex.ErrReplayJobNotExist
+	}
+	return codex.OK.WithData(newReplayJobResp(job))
+}
+
+func newReplayJobResp(job cache.ReplayJob) req.ReplayJobResp {
+	return req.ReplayJobResp{
+		JobId:        job.Id,
+		Status:       job.Status,
+		FromHeight:   int64(job.From),
+		ToHeight:     int64(job.To),
+		Height:       int64(job.Height),
+		TipSets:      job.TipSets,
+		Messages:     job.Messages,
+		Notified:     job.Notified,
+		TopLevelOnly: job.TopLevelOnly,
+		Error:        job.Error,
+	}
+}
+
 func (userApp UserAppHandler) GetAppWatch(ctx context.Context, appId, address string, fn func(model.UserAppSub) response.Response) response.Response {
 	userWatch, err := userApp.UserAppService.GetAppWatchByAppId(ctx, appId, address)
 	if err != nil {
@@ -74,11 +318,68 @@ func (userApp UserAppHandler) GetActorAddress(ctx context.Context, a string) (ad
 	actor := actoraddress.NewProxyActorAddress()
 	actorAddress, err := actor.GetActorAddress(ctx, nil, addr)
 	if err != nil {
+		// the actor of the robust address may not exist yet, the sub is upgraded to the ID address
+		// by the subscriber once the actor is created
+		if addr.Protocol() != address.ID {
+			log.Warnf("[GetActorAddress] lookup %v err:%v, subscribe the robust address", a, err)
+			return addr, nil
+		}
 		return address.Address{}, codex.ErrService.FormatErrMsg(err)
 	}
 	return actorAddress, nil
 }
 
+// GetValueRange parse the min and max value in FIL to the value range in attoFIL
+func (userApp UserAppHandler) GetValueRange(min, max string) (model.ValueRange, response.Response) {
+	var (
+		valueRange model.ValueRange
+		minValue   types.FIL
+		maxValue   types.FIL
+		err        error
+	)
+	if min != "" {
+		if minValue, err = types.ParseFIL(min); err != nil {
+			return valueRange, codex.ErrParamIllegal.FormatErrMsg(errors.Wrap(err, "minValue"))
+		}
+		if minValue.Int.Sign() < 0 {
+			return valueRange, codex.ErrParamIllegal.FormatErrMsg("minValue must not be negative")
+		}
+		valueRange.MinValue = minValue.Int.String()
+	}
+	if max != "" {
+		if maxValue, err = types.ParseFIL(max); err != nil {
+			return valueRange, codex.ErrParamIllegal.FormatErrMsg(errors.Wrap(err, "maxValue"))
+		}
+		if maxValue.Int.Sign() < 0 {
+			return valueRange, codex.ErrParamIllegal.FormatErrMsg("maxValue must not be negative")
+		}
+		valueRange.MaxValue = maxValue.Int.String()
+	}
+	if min != "" && max != "" && minValue.Int.Cmp(maxValue.Int) > 0 {
+		return valueRange, codex.ErrParamIllegal.FormatErrMsg("minValue is greater than maxValue")
+	}
+	return valueRange, nil
+}
+
+// AddSubAll create the subAll, or replace the filter if the app has subscribed it
+func (userApp UserAppHandler) AddSubAll(ctx context.Context, subAll model.UserAppSubAll) response.Response {
+	exist, err := userApp.UserAppService.GetWatchAllByAppId(ctx, subAll.AppId,
+		model.AllType(subAll.AllType), subAll.ActorFamily)
+	if err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	if !exist.IsEmpty() {
+		if err = userApp.UserAppService.UpdateSubAllFilter(ctx, subAll); err != nil {
+			return codex.ErrService.FormatErrMsg(err)
+		}
+		return codex.OK
+	}
+	if err = userApp.UserAppService.CreateSubAll(ctx, subAll); err != nil {
+		return codex.ErrService.FormatErrMsg(err)
+	}
+	return codex.OK
+}
+
 func (userApp UserAppHandler) AddSub(c *gin.Context) response.Response {
 	var (
 		param req.AddSubReq
@@ -91,40 +392,79 @@ func (userApp UserAppHandler) AddSub(c *gin.Context) response.Response {
 		return codex.ErrParamIllegal.FormatErrMsg(err)
 	}
 
-	// the appid want to subscribe all address
-	if param.IsAll() {
-		var (
-			subAll model.UserAppSubAll
-		)
-		subAll, err = userApp.UserAppService.GetWatchAllByAppId(c, appId)
-		if err != nil {
-			return codex.ErrService.FormatErrMsg(err)
+	valueRange, respErr := userApp.GetValueRange(param.MinValue, param.MaxValue)
+	if respErr != nil {
+		return respErr
+	}
+	// the expression is compiled and cached now, so that the messages are matched by the cached one,
+	// the error tells the position of the problem
+	if param.Expr != "" {
+		if _, err = subscriber.Sub.CompileExpr(param.Expr); err != nil {
+			return codex.ErrParamIllegal.FormatErrMsg(err)
 		}
-		if !subAll.IsEmpty() {
-			return codex.OK
+	}
+	subAllFilter := model.SubAllFilter{ValueRange: valueRange, Expr: param.Expr}
+	if len(param.Kinds) > 0 && (param.IsAll() || param.IsActor() || param.IsHead()) {
+		return codex.ErrParamIllegal.FormatErrMsg("kinds only work when subscribe an address")
+	}
+
+	// the appid want to be notified of each new head
+	if param.IsHead() {
+		if param.Expr != "" || param.MinValue != "" || param.MaxValue != "" {
+			return codex.ErrParamIllegal.FormatErrMsg("filters do not work when subscribe the heads")
 		}
-		subAll = model.NewDefaultAppSubAll()
+		subAll := model.NewDefaultAppSubAll()
 		subAll.AppId = appId
-		if err = userApp.UserAppService.CreateSubAll(c, subAll); err != nil {
-			return codex.ErrService.FormatErrMsg(err)
-		}
-		return codex.OK
+		subAll.AllType = int8(model.HeadAllType)
+		return userApp.AddSubAll(c, subAll)
+	}
+
+	// the appid want to subscribe all address
+	if param.IsAll() {
+		subAll := model.NewDefaultAppSubAll()
+		subAll.AppId = appId
+		subAll.SubAllFilter = subAllFilter
+		return userApp.AddSubAll(c, subAll)
+	}
+
+	// the appid want to subscribe all address of the actor family
+	if param.IsActor() {
+		subAll := model.NewDefaultAppSubAll()
+		subAll.AppId = appId
+		subAll.AllType = int8(model.ActorAllType)
+		subAll.ActorFamily = param.ActorFamily
+		subAll.SubAllFilter = subAllFilter
+		return userApp.AddSubAll(c, subAll)
 	}
 
 	// other, subscribe some address
 
 	resAddress, respErr := userApp.GetActorAddress(c, param.Address)
-	if err != nil {
+	if respErr != nil {
 		return respErr
 	}
 	param.Address = resAddress.String()
 
+	subFilter := model.SubFilter{
+		Methods:        param.Methods,
+		Direction:      param.Direction,
+		ExitCodePolicy: param.ExitCodePolicy,
+		ExitCodes:      param.ExitCodes,
+		ValueRange:     valueRange,
+		Expr:           param.Expr,
+		Kinds:          param.Kinds,
+	}
 	if respErr := userApp.GetAppWatch(c, appId,
 		param.Address, func(userWatch model.UserAppSub) response.Response {
-			if !userWatch.IsEmpty() {
-				return codex.OK
+			if userWatch.IsEmpty() {
+				return nil
 			}
-			return nil
+			// the address has been subscribed, just replace the filter
+			if err := userApp.UserAppService.UpdateSubFilter(c, appId,
+				param.Address, subFilter); err != nil {
+				return codex.ErrService.FormatErrMsg(err)
+			}
+			return codex.OK
 		}); respErr != nil {
 		return respErr
 	}
@@ -132,6 +472,7 @@ func (userApp UserAppHandler) AddSub(c *gin.Context) response.Response {
 	appWatchModel := model.NewDefaultAppSub()
 	appWatchModel.AppId = appId
 	appWatchModel.Address = param.Address
+	appWatchModel.SubFilter = subFilter
 	if err := userApp.UserAppService.AddSubAddress(c, appWatchModel); err != nil {
 		return codex.ErrService.FormatErrMsg(err)
 	}
@@ -151,14 +492,26 @@ func (userApp UserAppHandler) CancelSub(c *gin.Context) response.Response {
 	}
 	// the appid want to cancel all address
 	if param.IsAll() {
-		if err = userApp.UserAppService.CancelAll(c, appId); err != nil {
+		if err = userApp.UserAppService.CancelAll(c, appId, model.DefaultAllType, ""); err != nil {
+			return codex.ErrService.FormatErrMsg(err)
+		}
+		return codex.OK
+	}
+	if param.IsActor() {
+		if err = userApp.UserAppService.CancelAll(c, appId, model.ActorAllType, param.ActorFamily); err != nil {
+			return codex.ErrService.FormatErrMsg(err)
+		}
+		return codex.OK
+	}
+	if param.IsHead() {
+		if err = userApp.UserAppService.CancelAll(c, appId, model.HeadAllType, ""); err != nil {
 			return codex.ErrService.FormatErrMsg(err)
 		}
 		return codex.OK
 	}
 
 	resAddress, respErr := userApp.GetActorAddress(c, param.Address)
-	if err != nil {
+	if respErr != nil {
 		return respErr
 	}
 	param.Address = resAddress.String()
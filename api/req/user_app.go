@@ -1,5 +1,12 @@
 package req
 
+import (
+	"encoding/json"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+)
+
 type AppReq struct {
 	AppId     string `json:"appId" binding:"required,gt=0"`
 	AppSecret string `json:"appSecret" binding:"required,gt=0,lt=200"`
@@ -10,26 +17,166 @@ type ApplyResp struct {
 	AppSecret string `json:"appSecret"`
 }
 
+type UpdateAppReq struct {
+	// Confirmations the app is notified only after the tipset is some epochs deep, up to 900 the finality,
+	// 0 means at once. The notifications of the reverted tipsets are dropped before sent
+	Confirmations *int64 `json:"confirmations" binding:"required,gte=0,lte=900"`
+}
+
+type UpdateAppResp struct {
+}
+
 type AddSubReq struct {
-	SubType int8   `json:"subType" binding:"required,gte=1,lte=2"` //1 all address
+	SubType int8   `json:"subType" binding:"required,gte=1,lte=4"` //1 all address, 2 address, 3 actor family, 4 heads
 	Address string `json:"address" binding:"required_if=SubType 2,omitempty,gt=0,lte=300"`
+	// ActorFamily all the messages to the actors of the family are sent, e.g. storageminer, multisig
+	ActorFamily string `json:"actorFamily" binding:"required_if=SubType 3,omitempty,oneof=account storageminer multisig paymentchannel storagemarket storagepower verifiedregistry init reward cron system"`
+	// Methods only the messages calling these methods of the address are sent, empty means all methods
+	Methods []abi.MethodNum `json:"methods" binding:"omitempty,lte=100"`
+	// Direction 0 both, 1 only inbound messages, 2 only outbound messages
+	Direction int8 `json:"direction" binding:"omitempty,gte=0,lte=2"`
+	// ExitCodePolicy 0 any, 1 only succeeded messages, 2 only failed messages, 3 only the exitCodes
+	ExitCodePolicy int8                `json:"exitCodePolicy" binding:"omitempty,gte=0,lte=3"`
+	ExitCodes      []exitcode.ExitCode `json:"exitCodes" binding:"required_if=ExitCodePolicy 3,omitempty,gt=0,lte=50"`
+	// MinValue and MaxValue limit the value of the messages, e.g. "100", "0.5 FIL" or "1000 attoFIL",
+	// it also works when subscribe all addresses
+	MinValue string `json:"minValue" binding:"omitempty,lte=60"`
+	MaxValue string `json:"maxValue" binding:"omitempty,lte=60"`
+	// Expr the filter expression, e.g. `method in [2, 3] && value >= 10fil && !is_subcall`,
+	// the fields are from, to, method, value, exit_code, actor_family, gas_used, height, is_subcall and implicit
+	Expr string `json:"expr" binding:"omitempty,lte=1000"`
+	// Kinds which kinds of notifications of the address are sent, empty means only the messages,
+	// sector the sector lifecycle events of the miner, deal the storage deal events of the client or the provider,
+	// multisig the pending transaction events of the multisig or its signers, verifreg the allowance changes of
+	// the verifier or the DataCap changes of the verified client, power the power claim changes of the miner or
+	// the network totals if the address is the power actor, actor_created the robust address gets an ID address or
+	// all new actors if the address is the init actor, deadline the partition faults, recoveries and terminations of
+	// the miner, deadline_reminder the Window PoSt deadline of the miner is opened or about to close, balance the
+	// balance changes of the address, pending the messages of the address enter or leave the mpool.
+	// It only works when subscribe an address
+	Kinds []string `json:"kinds" binding:"omitempty,lte=13,dive,oneof=message sector deal multisig verifreg power actor_created deadline deadline_reminder balance pending"`
 }
 
 func (addSub *AddSubReq) IsAll() bool {
 	return addSub.SubType == 1
 }
 
+func (addSub *AddSubReq) IsActor() bool {
+	return addSub.SubType == 3
+}
+
+// IsHead the app wants one notification for each new head, the filters do not work
+func (addSub *AddSubReq) IsHead() bool {
+	return addSub.SubType == 4
+}
+
 type AddSubResp struct {
 }
 
 type CancelSubAddressReq struct {
-	SubType int8   `json:"subType" binding:"required,gte=1,lte=2"`
-	Address string `json:"address" binding:"required_if=SubType 2,omitempty,gt=0,lte=300"`
+	SubType     int8   `json:"subType" binding:"required,gte=1,lte=4"`
+	Address     string `json:"address" binding:"required_if=SubType 2,omitempty,gt=0,lte=300"`
+	ActorFamily string `json:"actorFamily" binding:"required_if=SubType 3,omitempty,gt=0,lte=30"`
 }
 
 func (receiver CancelSubAddressReq) IsAll() bool {
 	return receiver.SubType == 1
 }
 
+func (receiver CancelSubAddressReq) IsActor() bool {
+	return receiver.SubType == 3
+}
+
+func (receiver CancelSubAddressReq) IsHead() bool {
+	return receiver.SubType == 4
+}
+
 type CancelSubAddressResp struct {
 }
+
+// ReplayReq replay the messages of the addresses executed in [FromHeight, ToHeight] to the app
+type ReplayReq struct {
+	Addresses  []string `json:"addresses" binding:"required,gt=0,lte=100,dive,gt=0,lte=300"`
+	FromHeight int64    `json:"fromHeight" binding:"gte=0"`
+	ToHeight   int64    `json:"toHeight" binding:"gtefield=FromHeight"`
+}
+
+// ReplayJobResp the progress of a replay job, Height the last height replayed
+type ReplayJobResp struct {
+	JobId      string `json:"jobId"`
+	Status     string `json:"status"`
+	FromHeight int64  `json:"fromHeight"`
+	ToHeight   int64  `json:"toHeight"`
+	Height     int64  `json:"height"`
+	TipSets    int    `json:"tipSets"`
+	Messages   int    `json:"messages"`
+	Notified   int    `json:"notified"`
+	// TopLevelOnly the sub calls and the implicit messages are not replayed, only the top level messages
+	TopLevelOnly bool   `json:"topLevelOnly"`
+	Error        string `json:"error,omitempty"`
+}
+
+type AddWebhookReq struct {
+	Url string `json:"url" binding:"required,url,startswith=https://,lte=300"`
+}
+
+type AddWebhookResp struct {
+	// SigningSecret the payloads posted to the webhooks of the app are signed by it
+	SigningSecret string `json:"signingSecret"`
+}
+
+type CancelWebhookReq struct {
+	Url string `json:"url" binding:"required,gt=0,lte=300"`
+}
+
+type ListWebhookResp struct {
+	Urls []string `json:"urls"`
+}
+
+type ListDeadLetterReq struct {
+	Page     int64 `form:"page" binding:"omitempty,gte=1"`
+	PageSize int64 `form:"pageSize" binding:"omitempty,gte=1,lte=100"`
+}
+
+type DeadLetterItem struct {
+	Id         string `json:"id"`
+	Channel    string `json:"channel"`
+	Url        string `json:"url,omitempty"`
+	Err        string `json:"err"`
+	Attempts   int    `json:"attempts"`
+	UpdateTime int64  `json:"updateTime"`
+	CreateTime int64  `json:"createTime"`
+	// Skipped how many notifications are skipped after it while the endpoint is down
+	Skipped int `json:"skipped"`
+	// Payload the notification, only returned when a dead letter is inspected
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Payloads the skipped notifications, only returned when a dead letter is inspected
+	Payloads []json.RawMessage `json:"payloads,omitempty"`
+}
+
+type ListDeadLetterResp struct {
+	Total int64             `json:"total"`
+	List  []*DeadLetterItem `json:"list"`
+}
+
+type StreamReq struct {
+	// Cursor resume after the notification, the Last-Event-ID header works too,
+	// empty means only the new notifications, 0-0 means all the notifications kept
+	Cursor string `form:"cursor" binding:"omitempty,lte=40"`
+}
+
+// StreamEvent a notification pushed by the websocket, or a StreamReset if Event is StreamResetEvent
+type StreamEvent struct {
+	Event   string          `json:"event,omitempty"`
+	Cursor  string          `json:"cursor"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// StreamResetEvent the notifications after the cursor of the client are trimmed, it should resync its state
+const StreamResetEvent = "reset"
+
+// StreamReset the stream goes on from First, the oldest notification kept
+type StreamReset struct {
+	Cursor string `json:"cursor"`
+	First  string `json:"first"`
+}
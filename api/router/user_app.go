@@ -21,8 +21,23 @@ func RegisterUserApp(e *gin.RouterGroup) {
 	g.POST("/token", authMiddleware.LoginHandler)
 	g.POST("/token/refresh", authMiddleware.RefreshHandler)
 
+	// the browsers can't set the header of the event source, the token can be in the query
+	e.GET("/stream", authMiddleware.MiddlewareFunc(), userAppHandler.Stream)
+
 	w := e.Group("/sub")
 	w.Use(authMiddleware.MiddlewareFunc())
 	w.POST("", response.Json(userAppHandler.AddSub))
 	w.DELETE("", response.Json(userAppHandler.CancelSub))
+	w.POST("/replay", response.Json(userAppHandler.Replay))
+	w.GET("/replay/:id", response.Json(userAppHandler.GetReplay))
+
+	a := e.Group("/app")
+	a.Use(authMiddleware.MiddlewareFunc())
+	a.PUT("", response.Json(userAppHandler.UpdateApp))
+	a.GET("/webhook", response.Json(userAppHandler.ListWebhooks))
+	a.POST("/webhook", response.Json(userAppHandler.AddWebhook))
+	a.DELETE("/webhook", response.Json(userAppHandler.CancelWebhook))
+	a.GET("/deadletter", response.Json(userAppHandler.ListDeadLetters))
+	a.GET("/deadletter/:id", response.Json(userAppHandler.GetDeadLetter))
+	a.POST("/deadletter/:id/redrive", response.Json(userAppHandler.RedriveDeadLetter))
 }
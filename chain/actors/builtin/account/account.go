@@ -10,7 +10,7 @@ import (
 
 	"github.com/filecoin-project/lotus/chain/types"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -28,30 +28,37 @@ func init() {
 	builtin.RegisterActorState(builtin0.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load0(store, root)
 	})
+	builtin.RegisterActorMethods(builtin0.AccountActorCodeID, exports0())
 
 	builtin.RegisterActorState(builtin2.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load2(store, root)
 	})
+	builtin.RegisterActorMethods(builtin2.AccountActorCodeID, exports2())
 
 	builtin.RegisterActorState(builtin3.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load3(store, root)
 	})
+	builtin.RegisterActorMethods(builtin3.AccountActorCodeID, exports3())
 
 	builtin.RegisterActorState(builtin4.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load4(store, root)
 	})
+	builtin.RegisterActorMethods(builtin4.AccountActorCodeID, exports4())
 
 	builtin.RegisterActorState(builtin5.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load5(store, root)
 	})
+	builtin.RegisterActorMethods(builtin5.AccountActorCodeID, exports5())
 
 	builtin.RegisterActorState(builtin6.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load6(store, root)
 	})
+	builtin.RegisterActorMethods(builtin6.AccountActorCodeID, exports6())
 
 	builtin.RegisterActorState(builtin7.AccountActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load7(store, root)
 	})
+	builtin.RegisterActorMethods(builtin7.AccountActorCodeID, exports7())
 }
 
 var Methods = builtin4.MethodsAccount
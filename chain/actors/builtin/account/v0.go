@@ -29,3 +29,7 @@ type state0 struct {
 func (s *state0) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports0() []interface{} {
+	return account0.Actor{}.Exports()
+}
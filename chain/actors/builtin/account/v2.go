@@ -29,3 +29,7 @@ type state2 struct {
 func (s *state2) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports2() []interface{} {
+	return account2.Actor{}.Exports()
+}
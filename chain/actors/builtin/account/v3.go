@@ -29,3 +29,7 @@ type state3 struct {
 func (s *state3) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports3() []interface{} {
+	return account3.Actor{}.Exports()
+}
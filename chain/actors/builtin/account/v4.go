@@ -29,3 +29,7 @@ type state4 struct {
 func (s *state4) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports4() []interface{} {
+	return account4.Actor{}.Exports()
+}
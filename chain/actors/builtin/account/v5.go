@@ -29,3 +29,7 @@ type state5 struct {
 func (s *state5) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports5() []interface{} {
+	return account5.Actor{}.Exports()
+}
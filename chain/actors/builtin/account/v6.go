@@ -29,3 +29,7 @@ type state6 struct {
 func (s *state6) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports6() []interface{} {
+	return account6.Actor{}.Exports()
+}
@@ -29,3 +29,7 @@ type state7 struct {
 func (s *state7) PubkeyAddress() (address.Address, error) {
 	return s.Address, nil
 }
+
+func exports7() []interface{} {
+	return account7.Actor{}.Exports()
+}
@@ -4,15 +4,34 @@ package cron
 import (
 	"github.com/ipfs/go-cid"
 
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+
 	builtin0 "github.com/filecoin-project/specs-actors/actors/builtin"
+	cron0 "github.com/filecoin-project/specs-actors/actors/builtin/cron"
 	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+	cron2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/cron"
 	builtin3 "github.com/filecoin-project/specs-actors/v3/actors/builtin"
+	cron3 "github.com/filecoin-project/specs-actors/v3/actors/builtin/cron"
 	builtin4 "github.com/filecoin-project/specs-actors/v4/actors/builtin"
+	cron4 "github.com/filecoin-project/specs-actors/v4/actors/builtin/cron"
 	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+	cron5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/cron"
 	builtin6 "github.com/filecoin-project/specs-actors/v6/actors/builtin"
+	cron6 "github.com/filecoin-project/specs-actors/v6/actors/builtin/cron"
 	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	cron7 "github.com/filecoin-project/specs-actors/v7/actors/builtin/cron"
 )
 
+func init() {
+	builtin.RegisterActorMethods(builtin0.CronActorCodeID, cron0.Actor{}.Exports())
+	builtin.RegisterActorMethods(builtin2.CronActorCodeID, cron2.Actor{}.Exports())
+	builtin.RegisterActorMethods(builtin3.CronActorCodeID, cron3.Actor{}.Exports())
+	builtin.RegisterActorMethods(builtin4.CronActorCodeID, cron4.Actor{}.Exports())
+	builtin.RegisterActorMethods(builtin5.CronActorCodeID, cron5.Actor{}.Exports())
+	builtin.RegisterActorMethods(builtin6.CronActorCodeID, cron6.Actor{}.Exports())
+	builtin.RegisterActorMethods(builtin7.CronActorCodeID, cron7.Actor{}.Exports())
+}
+
 var (
 	Address = builtin7.CronActorAddr
 	Methods = builtin7.MethodsCron
@@ -29,30 +29,37 @@ func init() {
 	builtin.RegisterActorState(builtin0.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load0(store, root)
 	})
+	builtin.RegisterActorMethods(builtin0.InitActorCodeID, exports0())
 
 	builtin.RegisterActorState(builtin2.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load2(store, root)
 	})
+	builtin.RegisterActorMethods(builtin2.InitActorCodeID, exports2())
 
 	builtin.RegisterActorState(builtin3.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load3(store, root)
 	})
+	builtin.RegisterActorMethods(builtin3.InitActorCodeID, exports3())
 
 	builtin.RegisterActorState(builtin4.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load4(store, root)
 	})
+	builtin.RegisterActorMethods(builtin4.InitActorCodeID, exports4())
 
 	builtin.RegisterActorState(builtin5.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load5(store, root)
 	})
+	builtin.RegisterActorMethods(builtin5.InitActorCodeID, exports5())
 
 	builtin.RegisterActorState(builtin6.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load6(store, root)
 	})
+	builtin.RegisterActorMethods(builtin6.InitActorCodeID, exports6())
 
 	builtin.RegisterActorState(builtin7.InitActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load7(store, root)
 	})
+	builtin.RegisterActorMethods(builtin7.InitActorCodeID, exports7())
 }
 
 var (
@@ -91,3 +91,7 @@ func (s *state0) Remove(addrs ...address.Address) (err error) {
 func (s *state0) addressMap() (adt.Map, error) {
 	return adt0.AsMap(s.store, s.AddressMap)
 }
+
+func exports0() []interface{} {
+	return init0.Actor{}.Exports()
+}
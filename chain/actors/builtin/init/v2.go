@@ -91,3 +91,7 @@ func (s *state2) Remove(addrs ...address.Address) (err error) {
 func (s *state2) addressMap() (adt.Map, error) {
 	return adt2.AsMap(s.store, s.AddressMap)
 }
+
+func exports2() []interface{} {
+	return init2.Actor{}.Exports()
+}
@@ -91,3 +91,7 @@ func (s *state5) Remove(addrs ...address.Address) (err error) {
 func (s *state5) addressMap() (adt.Map, error) {
 	return adt5.AsMap(s.store, s.AddressMap, builtin5.DefaultHamtBitwidth)
 }
+
+func exports5() []interface{} {
+	return init5.Actor{}.Exports()
+}
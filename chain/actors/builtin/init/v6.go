@@ -91,3 +91,7 @@ func (s *state6) Remove(addrs ...address.Address) (err error) {
 func (s *state6) addressMap() (adt.Map, error) {
 	return adt6.AsMap(s.store, s.AddressMap, builtin6.DefaultHamtBitwidth)
 }
+
+func exports6() []interface{} {
+	return init6.Actor{}.Exports()
+}
@@ -91,3 +91,7 @@ func (s *state7) Remove(addrs ...address.Address) (err error) {
 func (s *state7) addressMap() (adt.Map, error) {
 	return adt7.AsMap(s.store, s.AddressMap, builtin7.DefaultHamtBitwidth)
 }
+
+func exports7() []interface{} {
+	return init7.Actor{}.Exports()
+}
@@ -23,7 +23,7 @@ import (
 
 	"github.com/filecoin-project/lotus/chain/types"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 )
@@ -33,30 +33,37 @@ func init() {
 	builtin.RegisterActorState(builtin0.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load0(store, root)
 	})
+	builtin.RegisterActorMethods(builtin0.StorageMarketActorCodeID, exports0())
 
 	builtin.RegisterActorState(builtin2.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load2(store, root)
 	})
+	builtin.RegisterActorMethods(builtin2.StorageMarketActorCodeID, exports2())
 
 	builtin.RegisterActorState(builtin3.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load3(store, root)
 	})
+	builtin.RegisterActorMethods(builtin3.StorageMarketActorCodeID, exports3())
 
 	builtin.RegisterActorState(builtin4.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load4(store, root)
 	})
+	builtin.RegisterActorMethods(builtin4.StorageMarketActorCodeID, exports4())
 
 	builtin.RegisterActorState(builtin5.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load5(store, root)
 	})
+	builtin.RegisterActorMethods(builtin5.StorageMarketActorCodeID, exports5())
 
 	builtin.RegisterActorState(builtin6.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load6(store, root)
 	})
+	builtin.RegisterActorMethods(builtin6.StorageMarketActorCodeID, exports6())
 
 	builtin.RegisterActorState(builtin7.StorageMarketActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load7(store, root)
 	})
+	builtin.RegisterActorMethods(builtin7.StorageMarketActorCodeID, exports7())
 }
 
 var (
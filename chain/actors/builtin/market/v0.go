@@ -222,3 +222,7 @@ func (s *state0) DealProposalsAmtBitwidth() int {
 func (s *state0) DealStatesAmtBitwidth() int {
 	return 3
 }
+
+func exports0() []interface{} {
+	return market0.Actor{}.Exports()
+}
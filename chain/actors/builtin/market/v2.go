@@ -222,3 +222,7 @@ func (s *state2) DealProposalsAmtBitwidth() int {
 func (s *state2) DealStatesAmtBitwidth() int {
 	return 3
 }
+
+func exports2() []interface{} {
+	return market2.Actor{}.Exports()
+}
@@ -222,3 +222,7 @@ func (s *state3) DealProposalsAmtBitwidth() int {
 func (s *state3) DealStatesAmtBitwidth() int {
 	return market3.StatesAmtBitwidth
 }
+
+func exports3() []interface{} {
+	return market3.Actor{}.Exports()
+}
@@ -222,3 +222,7 @@ func (s *state4) DealProposalsAmtBitwidth() int {
 func (s *state4) DealStatesAmtBitwidth() int {
 	return market4.StatesAmtBitwidth
 }
+
+func exports4() []interface{} {
+	return market4.Actor{}.Exports()
+}
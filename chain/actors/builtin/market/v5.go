@@ -222,3 +222,7 @@ func (s *state5) DealProposalsAmtBitwidth() int {
 func (s *state5) DealStatesAmtBitwidth() int {
 	return market5.StatesAmtBitwidth
 }
+
+func exports5() []interface{} {
+	return market5.Actor{}.Exports()
+}
@@ -222,3 +222,7 @@ func (s *state6) DealProposalsAmtBitwidth() int {
 func (s *state6) DealStatesAmtBitwidth() int {
 	return market6.StatesAmtBitwidth
 }
+
+func exports6() []interface{} {
+	return market6.Actor{}.Exports()
+}
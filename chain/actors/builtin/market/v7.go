@@ -222,3 +222,7 @@ func (s *state7) DealProposalsAmtBitwidth() int {
 func (s *state7) DealStatesAmtBitwidth() int {
 	return market7.StatesAmtBitwidth
 }
+
+func exports7() []interface{} {
+	return market7.Actor{}.Exports()
+}
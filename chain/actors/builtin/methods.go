@@ -0,0 +1,112 @@
+package builtin
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+)
+
+// MethodMeta describes an actor method, Params and Ret are the pointer types
+// of the params and the return value.
+type MethodMeta struct {
+	Name string
+
+	Params reflect.Type
+	Ret    reflect.Type
+}
+
+// ActorMethods the methods of the actors registered by the actor packages, code -> method number -> meta
+var ActorMethods = make(map[cid.Cid]map[abi.MethodNum]MethodMeta)
+
+// RegisterActorMethods registers the exported methods of an actor, the same way as the lotus invoker does.
+func RegisterActorMethods(code cid.Cid, exports []interface{}) {
+	methods := make(map[abi.MethodNum]MethodMeta, len(exports))
+	// Explicitly add send, it's special.
+	methods[MethodSend] = MethodMeta{
+		Name:   "Send",
+		Params: reflect.TypeOf(new(abi.EmptyValue)),
+		Ret:    reflect.TypeOf(new(abi.EmptyValue)),
+	}
+	// Some of the exported methods _may_ be nil and must be skipped.
+	for number, export := range exports {
+		if export == nil {
+			continue
+		}
+		ev := reflect.ValueOf(export)
+		et := ev.Type()
+
+		// The method names always match the field names in the `builtin.Method*` structs.
+		fnName := runtime.FuncForPC(ev.Pointer()).Name()
+		fnName = strings.TrimSuffix(fnName[strings.LastIndexByte(fnName, '.')+1:], "-fm")
+
+		methods[abi.MethodNum(number)] = MethodMeta{
+			Name:   fnName,
+			Params: et.In(1),
+			Ret:    et.Out(0),
+		}
+	}
+	ActorMethods[code] = methods
+}
+
+// GetMethodMeta returns false if the actor or the method is unknown
+func GetMethodMeta(code cid.Cid, method abi.MethodNum) (MethodMeta, bool) {
+	meta, ok := ActorMethods[code][method]
+	return meta, ok
+}
+
+// MethodName returns the name of the method, e.g. PublishStorageDeals. Send and Constructor are
+// reserved by all the actors, so they are named even if the actor is unknown.
+func MethodName(code cid.Cid, method abi.MethodNum) string {
+	if meta, ok := GetMethodMeta(code, method); ok {
+		return meta.Name
+	}
+	switch method {
+	case MethodSend:
+		return "Send"
+	case MethodConstructor:
+		return "Constructor"
+	}
+	return "<unknown>"
+}
+
+// DecodeParams decodes the params of the method into its typed value, nil if the params is empty
+func DecodeParams(code cid.Cid, method abi.MethodNum, params []byte) (interface{}, error) {
+	meta, ok := GetMethodMeta(code, method)
+	if !ok {
+		return nil, xerrors.Errorf("method %d not found on actor %s", method, code)
+	}
+	return decode(meta.Params, params)
+}
+
+// DecodeReturn decodes the return value of the method into its typed value, nil if the return is empty
+func DecodeReturn(code cid.Cid, method abi.MethodNum, ret []byte) (interface{}, error) {
+	meta, ok := GetMethodMeta(code, method)
+	if !ok {
+		return nil, xerrors.Errorf("method %d not found on actor %s", method, code)
+	}
+	return decode(meta.Ret, ret)
+}
+
+func decode(typ reflect.Type, b []byte) (interface{}, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if typ.Kind() != reflect.Ptr {
+		return nil, xerrors.Errorf("type %s is not a pointer", typ)
+	}
+	out := reflect.New(typ.Elem()).Interface()
+	um, ok := out.(cbg.CBORUnmarshaler)
+	if !ok {
+		return nil, xerrors.Errorf("type %T does not implement UnmarshalCBOR", out)
+	}
+	if err := um.UnmarshalCBOR(bytes.NewReader(b)); err != nil {
+		return nil, xerrors.Errorf("unmarshaling %T: %w", out, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package builtin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin0 "github.com/filecoin-project/specs-actors/actors/builtin"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	miner7 "github.com/filecoin-project/specs-actors/v7/actors/builtin/miner"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/cron"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/market"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/miner"
+)
+
+func TestGetMethodMeta(t *testing.T) {
+	tests := []struct {
+		name   string
+		method abi.MethodNum
+		want   string
+		found  bool
+	}{
+		{"send", builtin7.MethodSend, "Send", true},
+		{"constructor", builtin7.MethodConstructor, "Constructor", true},
+		{"changeWorker", builtin7.MethodsMiner.ChangeWorkerAddress, "ChangeWorkerAddress", true},
+		{"unknown", 1000, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, ok := builtin.GetMethodMeta(builtin7.StorageMinerActorCodeID, tt.method)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.want, meta.Name)
+		})
+	}
+}
+
+func TestMethodName(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   cid.Cid
+		method abi.MethodNum
+		want   string
+	}{
+		{"market", builtin0.StorageMarketActorCodeID, builtin0.MethodsMarket.PublishStorageDeals, "PublishStorageDeals"},
+		{"cron", builtin7.CronActorCodeID, builtin7.MethodsCron.EpochTick, "EpochTick"},
+		{"unknownSend", cid.Undef, builtin7.MethodSend, "Send"},
+		{"unknownConstructor", builtin7.SystemActorCodeID, builtin7.MethodConstructor, "Constructor"},
+		{"unknown", cid.Undef, 2, "<unknown>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, builtin.MethodName(tt.code, tt.method))
+		})
+	}
+}
+
+func TestDecodeParams(t *testing.T) {
+	worker, err := address.NewFromString("t01000")
+	assert.Nil(t, err)
+	params := miner7.ChangeWorkerAddressParams{NewWorker: worker, NewControlAddrs: []address.Address{worker}}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, params.MarshalCBOR(buf))
+
+	decoded, err := builtin.DecodeParams(builtin7.StorageMinerActorCodeID,
+		builtin7.MethodsMiner.ChangeWorkerAddress, buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, &params, decoded)
+
+	// the return value of ChangeWorkerAddress is empty
+	ret, err := builtin.DecodeReturn(builtin7.StorageMinerActorCodeID,
+		builtin7.MethodsMiner.ChangeWorkerAddress, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, ret)
+
+	_, err = builtin.DecodeParams(builtin7.AccountActorCodeID, 2, buf.Bytes())
+	assert.NotNil(t, err)
+}
@@ -17,7 +17,7 @@ import (
 
 	"github.com/filecoin-project/lotus/chain/types"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -39,30 +39,37 @@ func init() {
 	builtin.RegisterActorState(builtin0.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load0(store, root)
 	})
+	builtin.RegisterActorMethods(builtin0.StorageMinerActorCodeID, exports0())
 
 	builtin.RegisterActorState(builtin2.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load2(store, root)
 	})
+	builtin.RegisterActorMethods(builtin2.StorageMinerActorCodeID, exports2())
 
 	builtin.RegisterActorState(builtin3.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load3(store, root)
 	})
+	builtin.RegisterActorMethods(builtin3.StorageMinerActorCodeID, exports3())
 
 	builtin.RegisterActorState(builtin4.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load4(store, root)
 	})
+	builtin.RegisterActorMethods(builtin4.StorageMinerActorCodeID, exports4())
 
 	builtin.RegisterActorState(builtin5.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load5(store, root)
 	})
+	builtin.RegisterActorMethods(builtin5.StorageMinerActorCodeID, exports5())
 
 	builtin.RegisterActorState(builtin6.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load6(store, root)
 	})
+	builtin.RegisterActorMethods(builtin6.StorageMinerActorCodeID, exports6())
 
 	builtin.RegisterActorState(builtin7.StorageMinerActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load7(store, root)
 	})
+	builtin.RegisterActorMethods(builtin7.StorageMinerActorCodeID, exports7())
 
 }
 
@@ -436,3 +436,7 @@ func fromV0SectorPreCommitOnChainInfo(v0 miner0.SectorPreCommitOnChainInfo) Sect
 	return (SectorPreCommitOnChainInfo)(v0)
 
 }
+
+func exports0() []interface{} {
+	return miner0.Actor{}.Exports()
+}
@@ -452,3 +452,7 @@ func fromV2SectorPreCommitOnChainInfo(v2 miner2.SectorPreCommitOnChainInfo) Sect
 	}
 
 }
+
+func exports2() []interface{} {
+	return miner2.Actor{}.Exports()
+}
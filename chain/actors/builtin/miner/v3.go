@@ -451,3 +451,7 @@ func fromV3SectorPreCommitOnChainInfo(v3 miner3.SectorPreCommitOnChainInfo) Sect
 	}
 
 }
+
+func exports3() []interface{} {
+	return miner3.Actor{}.Exports()
+}
@@ -451,3 +451,7 @@ func fromV4SectorPreCommitOnChainInfo(v4 miner4.SectorPreCommitOnChainInfo) Sect
 	}
 
 }
+
+func exports4() []interface{} {
+	return miner4.Actor{}.Exports()
+}
@@ -451,3 +451,7 @@ func fromV5SectorPreCommitOnChainInfo(v5 miner5.SectorPreCommitOnChainInfo) Sect
 	}
 
 }
+
+func exports5() []interface{} {
+	return miner5.Actor{}.Exports()
+}
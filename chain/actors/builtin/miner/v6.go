@@ -451,3 +451,7 @@ func fromV6SectorPreCommitOnChainInfo(v6 miner6.SectorPreCommitOnChainInfo) Sect
 	}
 
 }
+
+func exports6() []interface{} {
+	return miner6.Actor{}.Exports()
+}
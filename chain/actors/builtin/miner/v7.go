@@ -451,3 +451,7 @@ func fromV7SectorPreCommitOnChainInfo(v7 miner7.SectorPreCommitOnChainInfo) Sect
 	}
 
 }
+
+func exports7() []interface{} {
+	return miner7.Actor{}.Exports()
+}
@@ -13,8 +13,8 @@ import (
 
 	"github.com/filecoin-project/lotus/chain/types"
 
-	"bony/chain/actors"
-	init_ "bony/chain/actors/builtin/init"
+	"github.com/bitrainforest/pulsar/chain/actors"
+	init_ "github.com/bitrainforest/pulsar/chain/actors/builtin/init"
 )
 
 type message0 struct{ from address.Address }
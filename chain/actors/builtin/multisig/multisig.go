@@ -25,8 +25,8 @@ import (
 
 	"github.com/filecoin-project/lotus/chain/types"
 
-	"bony/chain/actors"
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 )
@@ -36,30 +36,37 @@ func init() {
 	builtin.RegisterActorState(builtin0.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load0(store, root)
 	})
+	builtin.RegisterActorMethods(builtin0.MultisigActorCodeID, exports0())
 
 	builtin.RegisterActorState(builtin2.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load2(store, root)
 	})
+	builtin.RegisterActorMethods(builtin2.MultisigActorCodeID, exports2())
 
 	builtin.RegisterActorState(builtin3.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load3(store, root)
 	})
+	builtin.RegisterActorMethods(builtin3.MultisigActorCodeID, exports3())
 
 	builtin.RegisterActorState(builtin4.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load4(store, root)
 	})
+	builtin.RegisterActorMethods(builtin4.MultisigActorCodeID, exports4())
 
 	builtin.RegisterActorState(builtin5.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load5(store, root)
 	})
+	builtin.RegisterActorMethods(builtin5.MultisigActorCodeID, exports5())
 
 	builtin.RegisterActorState(builtin6.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load6(store, root)
 	})
+	builtin.RegisterActorMethods(builtin6.MultisigActorCodeID, exports6())
 
 	builtin.RegisterActorState(builtin7.MultisigActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
 		return load7(store, root)
 	})
+	builtin.RegisterActorMethods(builtin7.MultisigActorCodeID, exports7())
 }
 
 func Load(store adt.Store, act *types.Actor) (State, error) {
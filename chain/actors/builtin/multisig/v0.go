@@ -98,3 +98,7 @@ func (s *state0) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports0() []interface{} {
+	return msig0.Actor{}.Exports()
+}
@@ -98,3 +98,7 @@ func (s *state2) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports2() []interface{} {
+	return msig2.Actor{}.Exports()
+}
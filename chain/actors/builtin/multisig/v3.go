@@ -98,3 +98,7 @@ func (s *state3) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports3() []interface{} {
+	return msig3.Actor{}.Exports()
+}
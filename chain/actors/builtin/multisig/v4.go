@@ -98,3 +98,7 @@ func (s *state4) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports4() []interface{} {
+	return msig4.Actor{}.Exports()
+}
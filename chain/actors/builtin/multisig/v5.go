@@ -98,3 +98,7 @@ func (s *state5) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports5() []interface{} {
+	return msig5.Actor{}.Exports()
+}
@@ -98,3 +98,7 @@ func (s *state6) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports6() []interface{} {
+	return msig6.Actor{}.Exports()
+}
@@ -98,3 +98,7 @@ func (s *state7) decodeTransaction(val *cbg.Deferred) (Transaction, error) {
 	}
 	return tx, nil
 }
+
+func exports7() []interface{} {
+	return msig7.Actor{}.Exports()
+}
@@ -3,7 +3,7 @@ package mock
 import (
 	"io"
 
-	"bony/chain/actors/builtin/paych"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/paych"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -103,3 +103,7 @@ func (ls *laneState0) Redeemed() (big.Int, error) {
 func (ls *laneState0) Nonce() (uint64, error) {
 	return ls.LaneState.Nonce, nil
 }
+
+func exports0() []interface{} {
+	return paych0.Actor{}.Exports()
+}
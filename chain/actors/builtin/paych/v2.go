@@ -103,3 +103,7 @@ func (ls *laneState2) Redeemed() (big.Int, error) {
 func (ls *laneState2) Nonce() (uint64, error) {
 	return ls.LaneState.Nonce, nil
 }
+
+func exports2() []interface{} {
+	return paych2.Actor{}.Exports()
+}
@@ -103,3 +103,7 @@ func (ls *laneState3) Redeemed() (big.Int, error) {
 func (ls *laneState3) Nonce() (uint64, error) {
 	return ls.LaneState.Nonce, nil
 }
+
+func exports3() []interface{} {
+	return paych3.Actor{}.Exports()
+}
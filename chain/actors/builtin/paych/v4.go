@@ -103,3 +103,7 @@ func (ls *laneState4) Redeemed() (big.Int, error) {
 func (ls *laneState4) Nonce() (uint64, error) {
 	return ls.LaneState.Nonce, nil
 }
+
+func exports4() []interface{} {
+	return paych4.Actor{}.Exports()
+}
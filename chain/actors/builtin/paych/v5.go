@@ -103,3 +103,7 @@ func (ls *laneState5) Redeemed() (big.Int, error) {
 func (ls *laneState5) Nonce() (uint64, error) {
 	return ls.LaneState.Nonce, nil
 }
+
+func exports5() []interface{} {
+	return paych5.Actor{}.Exports()
+}
@@ -103,3 +103,7 @@ func (ls *laneState6) Redeemed() (big.Int, error) {
 func (ls *laneState6) Nonce() (uint64, error) {
 	return ls.LaneState.Nonce, nil
 }
+
+func exports6() []interface{} {
+	return paych6.Actor{}.Exports()
+}
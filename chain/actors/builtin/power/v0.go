@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV0Claim(v0 power0.Claim) Claim {
 		QualityAdjPower: v0.QualityAdjPower,
 	}
 }
+
+func exports0() []interface{} {
+	return power0.Actor{}.Exports()
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV2Claim(v2 power2.Claim) Claim {
 		QualityAdjPower: v2.QualityAdjPower,
 	}
 }
+
+func exports2() []interface{} {
+	return power2.Actor{}.Exports()
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV3Claim(v3 power3.Claim) Claim {
 		QualityAdjPower: v3.QualityAdjPower,
 	}
 }
+
+func exports3() []interface{} {
+	return power3.Actor{}.Exports()
+}
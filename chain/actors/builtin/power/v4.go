@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV4Claim(v4 power4.Claim) Claim {
 		QualityAdjPower: v4.QualityAdjPower,
 	}
 }
+
+func exports4() []interface{} {
+	return power4.Actor{}.Exports()
+}
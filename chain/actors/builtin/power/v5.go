@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV5Claim(v5 power5.Claim) Claim {
 		QualityAdjPower: v5.QualityAdjPower,
 	}
 }
+
+func exports5() []interface{} {
+	return power5.Actor{}.Exports()
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV6Claim(v6 power6.Claim) Claim {
 		QualityAdjPower: v6.QualityAdjPower,
 	}
 }
+
+func exports6() []interface{} {
+	return power6.Actor{}.Exports()
+}
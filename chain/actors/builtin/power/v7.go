@@ -9,7 +9,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 
-	"bony/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -153,3 +153,7 @@ func fromV7Claim(v7 power7.Claim) Claim {
 		QualityAdjPower: v7.QualityAdjPower,
 	}
 }
+
+func exports7() []interface{} {
+	return power7.Actor{}.Exports()
+}
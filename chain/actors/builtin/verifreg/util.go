@@ -1,7 +1,7 @@
 package verifreg
 
 import (
-	"bony/chain/actors"
+	"github.com/bitrainforest/pulsar/chain/actors"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
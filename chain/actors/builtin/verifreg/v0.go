@@ -6,7 +6,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 
-	"bony/chain/actors"
+	"github.com/bitrainforest/pulsar/chain/actors"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -62,3 +62,7 @@ func (s *state0) verifiedClients() (adt.Map, error) {
 func (s *state0) verifiers() (adt.Map, error) {
 	return adt0.AsMap(s.store, s.Verifiers)
 }
+
+func exports0() []interface{} {
+	return verifreg0.Actor{}.Exports()
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 
-	"bony/chain/actors"
+	"github.com/bitrainforest/pulsar/chain/actors"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -62,3 +62,7 @@ func (s *state2) verifiedClients() (adt.Map, error) {
 func (s *state2) verifiers() (adt.Map, error) {
 	return adt2.AsMap(s.store, s.Verifiers)
 }
+
+func exports2() []interface{} {
+	return verifreg2.Actor{}.Exports()
+}
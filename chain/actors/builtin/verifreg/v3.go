@@ -6,7 +6,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 
-	"bony/chain/actors"
+	"github.com/bitrainforest/pulsar/chain/actors"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -62,3 +62,7 @@ func (s *state3) verifiedClients() (adt.Map, error) {
 func (s *state3) verifiers() (adt.Map, error) {
 	return adt3.AsMap(s.store, s.Verifiers, builtin3.DefaultHamtBitwidth)
 }
+
+func exports3() []interface{} {
+	return verifreg3.Actor{}.Exports()
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 
-	"bony/chain/actors"
+	"github.com/bitrainforest/pulsar/chain/actors"
 
 	"github.com/bitrainforest/pulsar/chain/actors/adt"
 
@@ -62,3 +62,7 @@ func (s *state4) verifiedClients() (adt.Map, error) {
 func (s *state4) verifiers() (adt.Map, error) {
 	return adt4.AsMap(s.store, s.Verifiers, builtin4.DefaultHamtBitwidth)
 }
+
+func exports4() []interface{} {
+	return verifreg4.Actor{}.Exports()
+}
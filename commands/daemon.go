@@ -188,6 +188,14 @@ func (d *Daemon) Start(ctx context.Context) error {
 		}
 	}
 
+	// the messages of the subscribed addresses are published once they enter the mpool
+	mpoolUpdates, err := api.MpoolSub(ctx)
+	if err != nil {
+		stop(ctx)                                           //nolint
+		return xerrors.Errorf("subscribing mpool: %w", err) //nolint
+	}
+	d.core.SubscribeMpool(mpoolUpdates)
+
 	endpoint, err := r.APIEndpoint()
 	if err != nil {
 		return xerrors.Errorf("getting api endpoint: %w", err) //nolint
@@ -2,6 +2,9 @@ package commands
 
 import (
 	"strconv"
+	"time"
+
+	"github.com/bitrainforest/pulsar/internal/service/subscriber"
 
 	"github.com/bitrainforest/filmeta-hic/core/assert"
 	hicConf "github.com/bitrainforest/filmeta-hic/core/config"
@@ -10,8 +13,30 @@ import (
 )
 
 type NatsConf struct {
-	Addr string `json:"addr"`
-	Port int64  `json:"port"`
+	Addr      string        `json:"addr"`
+	Port      int64         `json:"port"`
+	JetStream JetStreamConf `json:"jetstream"`
+}
+
+// JetStreamConf publish the notifications to the stream of each app instead of the core nats,
+// the zero limits mean unlimited, except maxAgeHours which is 72 by default
+type JetStreamConf struct {
+	Enable      bool  `json:"enable"`
+	MaxAgeHours int64 `json:"maxAgeHours"`
+	MaxMsgs     int64 `json:"maxMsgs"`
+	MaxBytes    int64 `json:"maxBytes"`
+	Replicas    int   `json:"replicas"`
+	Memory      bool  `json:"memory"`
+}
+
+func (conf *JetStreamConf) GetRetention() subscriber.JetStreamRetention {
+	return subscriber.JetStreamRetention{
+		MaxAge:   time.Duration(conf.MaxAgeHours) * time.Hour,
+		MaxMsgs:  conf.MaxMsgs,
+		MaxBytes: conf.MaxBytes,
+		Replicas: conf.Replicas,
+		Memory:   conf.Memory,
+	}
 }
 
 func (conf *NatsConf) isEmpty() bool {
@@ -9,6 +9,7 @@ import (
 	"github.com/bitrainforest/filmeta-hic/core/store"
 	"github.com/bitrainforest/pulsar/api/middleware"
 	"github.com/bitrainforest/pulsar/api/router"
+	"github.com/bitrainforest/pulsar/internal/cache"
 	"github.com/bitrainforest/pulsar/internal/dao"
 	"github.com/bitrainforest/pulsar/internal/model"
 	"github.com/bitrainforest/pulsar/internal/service/subscriber"
@@ -67,6 +68,9 @@ var (
 				router.Register(engine, fixedEnv)
 			}, nil, httpOpts...)
 
+			// the indexes must exist before the apps register the webhooks
+			assert.CheckErr(dao.EnsureIndexes(context.Context))
+
 			//  daemon service
 			core, err := mustInitSubCore(context.Context)
 			assert.CheckErr(err)
@@ -118,28 +122,101 @@ func mustLoadConf() {
 	store.MustLoadRedis(conf)
 }
 
-func mustInitSubAllAddressAppId(ctx context.Context) (appIds []string) {
+func mustInitSubAllAddress(ctx context.Context) []*model.UserAppSubAll {
 	subAll := dao.NewUserAppSubAllDao()
 	list, err := subAll.ListByAllType(ctx, model.DefaultAllType)
 	assert.CheckErr(err)
+	actorList, err := subAll.ListByAllType(ctx, model.ActorAllType)
+	assert.CheckErr(err)
+	headList, err := subAll.ListByAllType(ctx, model.HeadAllType)
+	assert.CheckErr(err)
+	return append(append(list, actorList...), headList...)
+}
+func mustInitConfirmedApps(ctx context.Context) []*model.UserApp {
+	list, err := dao.NewUserAppDao().ListConfirmed(ctx)
+	assert.CheckErr(err)
+	return list
+}
+
+// mustInitWebhooks the endpoints of the apps, with the signing secret of each app.
+// The webhooks of a missing app are skipped, they're deleted if the app doesn't exist any more
+func mustInitWebhooks(ctx context.Context) map[string]subscriber.WebhookEndpoints {
+	webhookDao := dao.NewUserAppWebhookDao()
+	list, err := webhookDao.List(ctx)
+	assert.CheckErr(err)
+	appDao := dao.NewUserAppDao()
+	webhooks := make(map[string]subscriber.WebhookEndpoints)
+	missing := make(map[string]bool)
 	for _, v := range list {
-		appIds = append(appIds, v.AppId)
+		if missing[v.AppId] {
+			continue
+		}
+		endpoints, ok := webhooks[v.AppId]
+		if !ok {
+			app, err := appDao.GetByAppId(ctx, v.AppId)
+			if err != nil {
+				log.Errorf("[mustInitWebhooks] get app %v of webhook %v err:%v", v.AppId, v.Url, err)
+				missing[v.AppId] = true
+				continue
+			}
+			if app.IsEmpty() {
+				log.Warnf("[mustInitWebhooks] app %v of webhook %v doesn't exist", v.AppId, v.Url)
+				missing[v.AppId] = true
+				if err = webhookDao.DeleteByAppId(ctx, v.AppId); err != nil {
+					log.Errorf("[mustInitWebhooks] delete webhooks of app %v err:%v", v.AppId, err)
+				}
+				continue
+			}
+			endpoints.Secret = app.SigningSecret
+		}
+		endpoints.Urls = append(endpoints.Urls, v.Url)
+		webhooks[v.AppId] = endpoints
 	}
-	return appIds
+	return webhooks
 }
+
 func mustInitSubCore(ctx context.Context) (*subscriber.Core, error) {
-	initAppIds := mustInitSubAllAddressAppId(ctx)
+	subAllList := mustInitSubAllAddress(ctx)
 	//init nats client
 	natsConf := MustLoadNats(conf)
-	notify, err := subscriber.NewNotify(natsConf.GetUri())
+	var (
+		natsNotify subscriber.Notify
+		err        error
+	)
+	// the streams keep the notifications for the apps who are offline
+	if natsConf.JetStream.Enable {
+		natsNotify, err = subscriber.NewJetStreamNotify(natsConf.GetUri(), natsConf.JetStream.GetRetention())
+	} else {
+		natsNotify, err = subscriber.NewNotify(natsConf.GetUri())
+	}
 	if err != nil {
 		return nil, err
 	}
+	// the apps who can't run a nats client receive the notifications by the webhooks
+	webhook := subscriber.NewWebhookNotify()
+	for appId, endpoints := range mustInitWebhooks(ctx) {
+		webhook.SetEndpoints(appId, endpoints)
+	}
+	// the streaming clients read the notifications of the apps from the redis streams
+	stream := subscriber.NewStreamNotify(cache.NewNotifyStream(ctx))
+	notify := subscriber.NewMultiNotify(natsNotify, webhook, stream)
 	// init subscriber
-	sub, err := subscriber.NewSub(initAppIds, notify)
+	sub, err := subscriber.NewSub(nil, notify)
 	if err != nil {
 		return nil, err
 	}
+	// the apps subscribe all addresses or the actor families, with the value range of each app
+	for _, v := range subAllList {
+		sub.AppendSubAll(*v)
+	}
+	// the apps who want to be notified after the tipsets are confirmed
+	for _, v := range mustInitConfirmedApps(ctx) {
+		sub.SetConfirmations(v.AppId, v.Confirmations)
+	}
+	// the notifications held before the restart are released as the head advances
+	if err = sub.LoadUnconfirmed(ctx); err != nil {
+		return nil, err
+	}
 	// init core
 	core := subscriber.NewCore(sub)
 	return core, nil
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bitrainforest/pulsar/api/middleware"
+	"github.com/bitrainforest/pulsar/api/req"
+	"github.com/bitrainforest/pulsar/internal/cache"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var replayFlags struct {
+	api       string
+	appId     string
+	appSecret string
+	addresses cli.StringSlice
+	from      int64
+	to        int64
+}
+
+// replayPollInterval how often the progress of the replay job is read
+const replayPollInterval = 5 * time.Second
+
+type replayResult struct {
+	Code int64  `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+var (
+	// ReplayCommand ask the pulsar service to deliver the historical messages of the addresses to the app
+	ReplayCommand = &cli.Command{
+		Name:  "replay",
+		Usage: "Deliver the messages of the addresses executed in a height range to the app again",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "api",
+				Usage:       "The address of the pulsar http service.",
+				EnvVars:     []string{"PULSAR_API"},
+				Value:       "http://127.0.0.1:8000",
+				Destination: &replayFlags.api,
+			},
+			&cli.StringFlag{
+				Name:        "app-id",
+				Usage:       "The app who receives the messages.",
+				EnvVars:     []string{"PULSAR_APP_ID"},
+				Required:    true,
+				Destination: &replayFlags.appId,
+			},
+			&cli.StringFlag{
+				Name:        "app-secret",
+				Usage:       "The secret of the app.",
+				EnvVars:     []string{"PULSAR_APP_SECRET"},
+				Required:    true,
+				Destination: &replayFlags.appSecret,
+			},
+			&cli.StringSliceFlag{
+				Name:        "address",
+				Usage:       "The addresses whose messages are replayed, can be repeated.",
+				Required:    true,
+				Destination: &replayFlags.addresses,
+			},
+			&cli.Int64Flag{
+				Name:        "from",
+				Usage:       "The lowest height to replay.",
+				Required:    true,
+				Destination: &replayFlags.from,
+			},
+			&cli.Int64Flag{
+				Name:        "to",
+				Usage:       "The highest height to replay.",
+				Required:    true,
+				Destination: &replayFlags.to,
+			},
+		},
+		Action: func(cctx *cli.Context) error {
+			client := resty.New().SetTimeout(30 * time.Second)
+			uri := strings.TrimSuffix(replayFlags.api, "/") + "/api/v1"
+
+			var token struct {
+				replayResult
+				Data middleware.TokenResp `json:"data"`
+			}
+			resp, err := client.R().SetContext(cctx.Context).
+				SetBody(req.AppReq{AppId: replayFlags.appId, AppSecret: replayFlags.appSecret}).
+				SetResult(&token).Post(uri + "/apply/token")
+			if err = checkReplayResp(resp, err, token.replayResult); err != nil {
+				return errors.Wrap(err, "login")
+			}
+
+			var job struct {
+				replayResult
+				Data req.ReplayJobResp `json:"data"`
+			}
+			resp, err = client.R().SetContext(cctx.Context).
+				SetAuthToken(token.Data.Token).
+				SetBody(req.ReplayReq{
+					Addresses:  replayFlags.addresses.Value(),
+					FromHeight: replayFlags.from,
+					ToHeight:   replayFlags.to,
+				}).
+				SetResult(&job).Post(uri + "/sub/replay")
+			if err = checkReplayResp(resp, err, job.replayResult); err != nil {
+				return errors.Wrap(err, "replay")
+			}
+			fmt.Printf("replay job %s started\n", job.Data.JobId)
+			if job.Data.TopLevelOnly {
+				fmt.Println("only the top level messages are replayed, the sub calls and the implicit messages are not")
+			}
+
+			// the job runs in the background, follow its progress until it finishes
+			jobId := job.Data.JobId
+			for job.Data.Status == cache.ReplayRunning {
+				select {
+				case <-cctx.Context.Done():
+					return cctx.Context.Err()
+				case <-time.After(replayPollInterval):
+				}
+				resp, err = client.R().SetContext(cctx.Context).
+					SetAuthToken(token.Data.Token).
+					SetResult(&job).Get(uri + "/sub/replay/" + jobId)
+				if err = checkReplayResp(resp, err, job.replayResult); err != nil {
+					return errors.Wrap(err, "replay progress")
+				}
+				fmt.Printf("replayed to %d, %d tipsets, %d messages, %d notified\n",
+					job.Data.Height, job.Data.TipSets, job.Data.Messages, job.Data.Notified)
+			}
+			if job.Data.Status == cache.ReplayFailed {
+				return fmt.Errorf("replay job %s failed: %s", jobId, job.Data.Error)
+			}
+			return nil
+		},
+	}
+)
+
+func checkReplayResp(resp *resty.Response, err error, result replayResult) error {
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("code:%d msg:%s", result.Code, result.Msg)
+	}
+	return nil
+}
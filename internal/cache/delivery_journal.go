@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/store"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/go-redis/redis/v8"
+	"github.com/ipfs/go-cid"
+)
+
+var (
+	_ DeliveryJournal = (*DeliveryJournalCache)(nil)
+)
+
+// DefaultJournalExpire the deliveries are kept for about the chain finality,
+// the tipsets older than that are never reverted
+const DefaultJournalExpire = 8 * time.Hour
+
+// Delivery a message or a state change event of a tipset which is delivered to the apps
+type Delivery struct {
+	// MCid the top-level message, Cid the message of the execution trace
+	MCid      cid.Cid  `json:"mcid"`
+	Cid       cid.Cid  `json:"cid"`
+	IsSubCall bool     `json:"is_sub_call"`
+	AppIds    []string `json:"app_ids"`
+	// Kind and Address the state change event, the cids are undefined then
+	Kind    string `json:"kind,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// DeliveryJournal records the messages delivered from the applied tipsets by the height,
+// so that the apps can be told when a tipset is reverted
+type DeliveryJournal interface {
+	Record(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey, delivery Delivery) error
+	Deliveries(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) ([]Delivery, error)
+	Forget(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) error
+}
+
+type DeliveryJournalCache struct {
+	store  *redis.Client
+	expire time.Duration
+}
+
+func NewDeliveryJournal(ctx context.Context) DeliveryJournal {
+	return &DeliveryJournalCache{
+		store:  store.GetRedisClient(ctx),
+		expire: DefaultJournalExpire,
+	}
+}
+
+func journalKey(height abi.ChainEpoch, tsk types.TipSetKey) string {
+	return fmt.Sprintf("delivered:%d:%s", height, tsk.String())
+}
+
+func (j *DeliveryJournalCache) Record(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey, delivery Delivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	key := journalKey(height, tsk)
+	_, err = j.store.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, data)
+		pipe.Expire(ctx, key, j.expire)
+		return nil
+	})
+	return err
+}
+
+func (j *DeliveryJournalCache) Deliveries(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) ([]Delivery, error) {
+	list, err := j.store.LRange(ctx, journalKey(height, tsk), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]Delivery, 0, len(list))
+	for _, item := range list {
+		var delivery Delivery
+		if err := json.Unmarshal([]byte(item), &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (j *DeliveryJournalCache) Forget(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) error {
+	return j.store.Del(ctx, journalKey(height, tsk)).Err()
+}
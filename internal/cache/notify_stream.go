@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/store"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	_ NotifyStream = (*NotifyStreamCache)(nil)
+)
+
+const (
+	// DefaultStreamMaxLen about how many of the latest notifications of each app are kept for the resumes
+	DefaultStreamMaxLen = 10000
+	// DefaultStreamExpire the stream of the app which is not notified for a day is removed
+	DefaultStreamExpire = 24 * time.Hour
+	// StreamBeginning the cursor to read the stream from the oldest notification kept
+	StreamBeginning = "0-0"
+
+	streamPayloadField = "payload"
+)
+
+// StreamEvent a notification in the stream of the app, the cursor is the id of the redis stream entry
+type StreamEvent struct {
+	Cursor  string
+	Payload []byte
+}
+
+// NotifyStream keeps the latest notifications of each app in a redis stream,
+// so that the streaming clients on any instance can read them and resume from a cursor
+type NotifyStream interface {
+	Append(ctx context.Context, appId string, payload []byte) (string, error)
+	// Read the notifications after the cursor, wait for the block duration if there is none
+	Read(ctx context.Context, appId, cursor string, count int64, block time.Duration) ([]StreamEvent, error)
+	// LastCursor the cursor of the latest notification, StreamBeginning if there is none
+	LastCursor(ctx context.Context, appId string) (string, error)
+	// FirstCursor the cursor of the oldest notification kept, empty if there is none
+	FirstCursor(ctx context.Context, appId string) (string, error)
+	// Touch mark the app has a streaming client, the notifications are appended for a while after
+	Touch(ctx context.Context, appId string) error
+	// Active whether the app has a streaming client lately
+	Active(ctx context.Context, appId string) (bool, error)
+}
+
+type NotifyStreamCache struct {
+	store  *redis.Client
+	maxLen int64
+	expire time.Duration
+}
+
+func NewNotifyStream(ctx context.Context) NotifyStream {
+	return &NotifyStreamCache{
+		store:  store.GetRedisClient(ctx),
+		maxLen: DefaultStreamMaxLen,
+		expire: DefaultStreamExpire,
+	}
+}
+
+func streamKey(appId string) string {
+	return fmt.Sprintf("notify_stream:%s", appId)
+}
+
+func streamActiveKey(appId string) string {
+	return fmt.Sprintf("notify_stream_active:%s", appId)
+}
+
+// CompareCursor compare the ids of the redis stream entries, the sequence is 0 if it's omitted
+func CompareCursor(a, b string) int {
+	aMs, aSeq := parseCursor(a)
+	bMs, bSeq := parseCursor(b)
+	if aMs != bMs {
+		return compareUint(aMs, bMs)
+	}
+	return compareUint(aSeq, bSeq)
+}
+
+func parseCursor(cursor string) (ms, seq uint64) {
+	parts := strings.SplitN(cursor, "-", 2)
+	ms, _ = strconv.ParseUint(parts[0], 10, 64)
+	if len(parts) == 2 {
+		seq, _ = strconv.ParseUint(parts[1], 10, 64)
+	}
+	return
+}
+
+func compareUint(a, b uint64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func (s *NotifyStreamCache) Append(ctx context.Context, appId string, payload []byte) (string, error) {
+	key := streamKey(appId)
+	var add *redis.StringCmd
+	_, err := s.store.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		add = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: s.maxLen,
+			Approx: true,
+			Values: map[string]interface{}{streamPayloadField: payload},
+		})
+		pipe.Expire(ctx, key, s.expire)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return add.Val(), nil
+}
+
+func (s *NotifyStreamCache) Read(ctx context.Context, appId, cursor string, count int64,
+	block time.Duration) ([]StreamEvent, error) {
+	streams, err := s.store.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{streamKey(appId), cursor},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var events []StreamEvent
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			payload, _ := msg.Values[streamPayloadField].(string)
+			events = append(events, StreamEvent{Cursor: msg.ID, Payload: []byte(payload)})
+		}
+	}
+	return events, nil
+}
+
+func (s *NotifyStreamCache) LastCursor(ctx context.Context, appId string) (string, error) {
+	msgs, err := s.store.XRevRangeN(ctx, streamKey(appId), "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return StreamBeginning, nil
+	}
+	return msgs[0].ID, nil
+}
+
+func (s *NotifyStreamCache) FirstCursor(ctx context.Context, appId string) (string, error) {
+	msgs, err := s.store.XRangeN(ctx, streamKey(appId), "-", "+", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "", nil
+	}
+	return msgs[0].ID, nil
+}
+
+// Touch the mark lasts as long as the stream, so that a client can resume as long as its notifications are kept
+func (s *NotifyStreamCache) Touch(ctx context.Context, appId string) error {
+	return s.store.Set(ctx, streamActiveKey(appId), 1, s.expire).Err()
+}
+
+func (s *NotifyStreamCache) Active(ctx context.Context, appId string) (bool, error) {
+	exists, err := s.store.Exists(ctx, streamActiveKey(appId)).Result()
+	return exists == 1, err
+}
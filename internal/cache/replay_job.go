@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/store"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	_ ReplayJobs = (*ReplayJobsCache)(nil)
+)
+
+// DefaultReplayJobExpire the replay jobs can be looked up for a day after their last progress
+const DefaultReplayJobExpire = 24 * time.Hour
+
+// the status of a replay job
+const (
+	ReplayRunning = "running"
+	ReplayDone    = "done"
+	ReplayFailed  = "failed"
+)
+
+// ReplayJob the progress of a replay which runs in the background
+type ReplayJob struct {
+	Id     string         `json:"id"`
+	AppId  string         `json:"app_id"`
+	From   abi.ChainEpoch `json:"from"`
+	To     abi.ChainEpoch `json:"to"`
+	Status string         `json:"status"`
+	// Height the last height replayed, From-1 before any tipset is replayed
+	Height   abi.ChainEpoch `json:"height"`
+	TipSets  int            `json:"tipsets"`
+	Messages int            `json:"messages"`
+	Notified int            `json:"notified"`
+	// TopLevelOnly only the top level messages are replayed, the sub calls and the implicit messages are not,
+	// since the receipts are read instead of executing the tipsets again
+	TopLevelOnly bool `json:"top_level_only"`
+	// Error why the job failed
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReplayJobs keeps the replay jobs, so that they can be looked up from any instance
+type ReplayJobs interface {
+	Save(ctx context.Context, job ReplayJob) error
+	// Get ok is false if the job doesn't exist or is expired
+	Get(ctx context.Context, id string) (job ReplayJob, ok bool, err error)
+}
+
+type ReplayJobsCache struct {
+	store  *redis.Client
+	expire time.Duration
+}
+
+func NewReplayJobs(ctx context.Context) ReplayJobs {
+	return &ReplayJobsCache{
+		store:  store.GetRedisClient(ctx),
+		expire: DefaultReplayJobExpire,
+	}
+}
+
+func replayJobKey(id string) string {
+	return "replay_job:" + id
+}
+
+func (r *ReplayJobsCache) Save(ctx context.Context, job ReplayJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.store.Set(ctx, replayJobKey(job.Id), data, r.expire).Err()
+}
+
+func (r *ReplayJobsCache) Get(ctx context.Context, id string) (job ReplayJob, ok bool, err error) {
+	data, err := r.store.Get(ctx, replayJobKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return job, false, nil
+	}
+	if err != nil {
+		return job, false, err
+	}
+	if err = json.Unmarshal(data, &job); err != nil {
+		return job, false, err
+	}
+	return job, true, nil
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bitrainforest/filmeta-hic/core/store"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	_ SubKindsVersion = (*SubKindsVersionCache)(nil)
+)
+
+const subKindsVersionKey = "sub_kinds_version"
+
+// SubKindsVersion the version of the subs of the state change kinds, it's bumped whenever they change,
+// so that the subscribers on every instance know when to reload them
+type SubKindsVersion interface {
+	// Version 0 if the subs never changed
+	Version(ctx context.Context) (int64, error)
+	Bump(ctx context.Context) error
+}
+
+type SubKindsVersionCache struct {
+	store *redis.Client
+}
+
+func NewSubKindsVersion(ctx context.Context) SubKindsVersion {
+	return &SubKindsVersionCache{
+		store: store.GetRedisClient(ctx),
+	}
+}
+
+func (s *SubKindsVersionCache) Version(ctx context.Context) (int64, error) {
+	version, err := s.store.Get(ctx, subKindsVersionKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (s *SubKindsVersionCache) Bump(ctx context.Context) error {
+	return s.store.Incr(ctx, subKindsVersionKey).Err()
+}
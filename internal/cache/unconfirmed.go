@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bitrainforest/filmeta-hic/core/store"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	_ UnconfirmedStore = (*UnconfirmedCache)(nil)
+)
+
+const unconfirmedKey = "unconfirmed"
+
+// Unconfirmed a notification held until its tipset is Confirmations epochs deep
+type Unconfirmed struct {
+	Id            string          `json:"id"`
+	Height        abi.ChainEpoch  `json:"height"`
+	TipSet        types.TipSetKey `json:"tipset"`
+	Confirmations abi.ChainEpoch  `json:"confirmations"`
+	AppIds        []string        `json:"app_ids"`
+	Payload       []byte          `json:"payload"`
+	// Delivery is journaled when the notification is released, nil if it's never reverted, e.g. a head
+	Delivery *Delivery `json:"delivery,omitempty"`
+}
+
+// UnconfirmedStore keeps the held notifications, so that they are released after a restart
+type UnconfirmedStore interface {
+	Save(ctx context.Context, item Unconfirmed) error
+	// Remove whether the notification is still held, only the instance which removes it releases it
+	Remove(ctx context.Context, id string) (bool, error)
+	List(ctx context.Context) ([]Unconfirmed, error)
+}
+
+type UnconfirmedCache struct {
+	store *redis.Client
+}
+
+func NewUnconfirmedStore(ctx context.Context) UnconfirmedStore {
+	return &UnconfirmedCache{
+		store: store.GetRedisClient(ctx),
+	}
+}
+
+func (u *UnconfirmedCache) Save(ctx context.Context, item Unconfirmed) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return u.store.HSet(ctx, unconfirmedKey, item.Id, data).Err()
+}
+
+func (u *UnconfirmedCache) Remove(ctx context.Context, id string) (bool, error) {
+	removed, err := u.store.HDel(ctx, unconfirmedKey, id).Result()
+	if err != nil {
+		return false, err
+	}
+	return removed == 1, nil
+}
+
+func (u *UnconfirmedCache) List(ctx context.Context) ([]Unconfirmed, error) {
+	values, err := u.store.HVals(ctx, unconfirmedKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Unconfirmed, 0, len(values))
+	for _, value := range values {
+		var item Unconfirmed
+		if err := json.Unmarshal([]byte(value), &item); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
@@ -0,0 +1,19 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+type DeadLetterDao interface {
+	Create(ctx context.Context, letter *model.DeadLetter) (err error)
+	GetById(ctx context.Context, appId, id string) (letter model.DeadLetter, err error)
+	// ListByAppId the dead letters of the app, the latest first
+	ListByAppId(ctx context.Context, appId string,
+		skip, limit int64) (list []*model.DeadLetter, total int64, err error)
+	UpdateErr(ctx context.Context, appId, id, errMsg string) (err error)
+	// AppendPayload add a notification skipped while the endpoint is down to the dead letter
+	AppendPayload(ctx context.Context, appId, id, payload string) (err error)
+	Delete(ctx context.Context, appId, id string) (err error)
+}
@@ -0,0 +1,109 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/bitrainforest/pulsar/internal/helper"
+	"github.com/bitrainforest/pulsar/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeadLetterDaoImpl struct {
+}
+
+func NewDeadLetterDao() DeadLetterDao {
+	return &DeadLetterDaoImpl{}
+}
+
+func (dead DeadLetterDaoImpl) GetCollection() *mongo.Collection {
+	return GetMongoDatabase().Collection("dead_letter")
+}
+
+// deadLetterFilter the dead letters are always accessed by their app
+func deadLetterFilter(appId, id string) (bson.M, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"_id": objectId, "app_id": appId}, nil
+}
+
+func (dead DeadLetterDaoImpl) Create(ctx context.Context, letter *model.DeadLetter) (err error) {
+	result, err := dead.GetCollection().InsertOne(ctx, letter)
+	if err != nil {
+		return
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		letter.Id = id
+	}
+	return
+}
+
+func (dead DeadLetterDaoImpl) GetById(ctx context.Context, appId, id string) (letter model.DeadLetter, err error) {
+	filter, err := deadLetterFilter(appId, id)
+	if err != nil {
+		return
+	}
+	result := dead.GetCollection().FindOne(ctx, filter)
+	err = helper.WarpMongoErr(result.Decode(&letter))
+	return
+}
+
+func (dead DeadLetterDaoImpl) ListByAppId(ctx context.Context, appId string,
+	skip, limit int64) (list []*model.DeadLetter, total int64, err error) {
+	filter := bson.M{"app_id": appId}
+	if total, err = dead.GetCollection().CountDocuments(ctx, filter); err != nil {
+		return
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetSkip(skip).SetLimit(limit)
+	cursor, err := dead.GetCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var letter model.DeadLetter
+		if err = cursor.Decode(&letter); err != nil {
+			return
+		}
+		list = append(list, &letter)
+	}
+	return
+}
+
+func (dead DeadLetterDaoImpl) UpdateErr(ctx context.Context, appId, id, errMsg string) (err error) {
+	filter, err := deadLetterFilter(appId, id)
+	if err != nil {
+		return
+	}
+	update := bson.M{"$set": bson.M{"err": errMsg, "update_time": time.Now().Unix()}}
+	_, err = dead.GetCollection().UpdateOne(ctx, filter, update)
+	return
+}
+
+func (dead DeadLetterDaoImpl) AppendPayload(ctx context.Context, appId, id, payload string) (err error) {
+	filter, err := deadLetterFilter(appId, id)
+	if err != nil {
+		return
+	}
+	update := bson.M{
+		"$push": bson.M{"payloads": payload},
+		"$set":  bson.M{"update_time": time.Now().Unix()},
+	}
+	_, err = dead.GetCollection().UpdateOne(ctx, filter, update)
+	return
+}
+
+func (dead DeadLetterDaoImpl) Delete(ctx context.Context, appId, id string) (err error) {
+	filter, err := deadLetterFilter(appId, id)
+	if err != nil {
+		return
+	}
+	_, err = dead.GetCollection().DeleteOne(ctx, filter)
+	err = helper.WarpMongoErr(err)
+	return
+}
@@ -0,0 +1,9 @@
+package dao
+
+import "context"
+
+// EnsureIndexes create the indexes the daos rely on, it's called when the service is started,
+// creating an index which exists already is a no-op
+func EnsureIndexes(ctx context.Context) error {
+	return NewUserAppWebhookDao().EnsureIndexes(ctx)
+}
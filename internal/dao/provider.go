@@ -3,5 +3,5 @@ package dao
 import "github.com/google/wire"
 
 var Provider = wire.NewSet(
-	NewUserAppDao, NewUserAppSubDao, NewUserAppSubAllDao,
+	NewUserAppDao, NewUserAppSubDao, NewUserAppSubAllDao, NewUserAppWebhookDao, NewDeadLetterDao,
 )
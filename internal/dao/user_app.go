@@ -12,4 +12,8 @@ type UserAppDao interface {
 	Create(ctx context.Context, userApp *model.UserApp) (err error)
 	GetByAppId(ctx context.Context,
 		appId string) (appModel model.UserApp, err error)
+	UpdateConfirmations(ctx context.Context, appId string, confirmations int64) (err error)
+	UpdateSigningSecret(ctx context.Context, appId, signingSecret string) (err error)
+	// ListConfirmed list the apps who want to be notified after the tipsets are confirmed
+	ListConfirmed(ctx context.Context) (list []*model.UserApp, err error)
 }
@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/bitrainforest/pulsar/internal/helper"
 	"github.com/bitrainforest/pulsar/internal/model"
@@ -40,3 +41,34 @@ func (app UserAppDaoImpl) Create(ctx context.Context, userApp *model.UserApp) (e
 	_, err = app.GetCollection().InsertOne(ctx, userApp)
 	return
 }
+
+func (app UserAppDaoImpl) UpdateConfirmations(ctx context.Context, appId string, confirmations int64) (err error) {
+	filter := bson.M{"app_id": appId}
+	update := bson.M{"$set": bson.M{"confirmations": confirmations, "update_time": time.Now().Unix()}}
+	_, err = app.GetCollection().UpdateOne(ctx, filter, update)
+	return
+}
+
+func (app UserAppDaoImpl) UpdateSigningSecret(ctx context.Context, appId, signingSecret string) (err error) {
+	filter := bson.M{"app_id": appId}
+	update := bson.M{"$set": bson.M{"signing_secret": signingSecret, "update_time": time.Now().Unix()}}
+	_, err = app.GetCollection().UpdateOne(ctx, filter, update)
+	return
+}
+
+func (app UserAppDaoImpl) ListConfirmed(ctx context.Context) (list []*model.UserApp, err error) {
+	filter := bson.M{"confirmations": bson.M{"$gt": 0}}
+	cursor, err := app.GetCollection().Find(ctx, filter)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var appModel model.UserApp
+		if err = cursor.Decode(&appModel); err != nil {
+			return
+		}
+		list = append(list, &appModel)
+	}
+	return
+}
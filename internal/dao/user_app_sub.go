@@ -9,12 +9,22 @@ import (
 type UserAppSubDao interface {
 	FindByAddress(ctx context.Context,
 		address string) (list []*model.UserAppSub, err error)
+	// FindByAddresses find the subs of the from and to address whose direction matches
 	FindByAddresses(ctx context.Context,
-		address []string) (list []*model.SpecialUserAppSub, err error)
+		from, to string) (list []*model.SpecialUserAppSub, err error)
+	// FindByKinds find the subs which subscribe any of the kinds
+	FindByKinds(ctx context.Context,
+		kinds []string) (list []*model.SpecialUserAppSub, err error)
 	Create(ctx context.Context,
 		appWatchModel *model.UserAppSub) (err error)
 	GetByAppId(ctx context.Context,
 		appId, address string) (appWatchModel model.UserAppSub, err error)
+	UpdateFilter(ctx context.Context,
+		appId, address string, filter model.SubFilter) (err error)
 	Cancel(ctx context.Context,
 		appId, address string) (err error)
+	// UpgradeAddress replace the address of the subs, e.g. the robust address gets an ID address,
+	// an app which already subscribes the new address keeps that sub, its sub of the old one is merged into it
+	UpgradeAddress(ctx context.Context,
+		from, to string) (upgraded int64, err error)
 }
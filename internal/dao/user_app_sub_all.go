@@ -9,10 +9,12 @@ import (
 type UserAppSubAllDao interface {
 	Create(ctx context.Context,
 		appWatchModel *model.UserAppSubAll) (err error)
+	UpdateFilter(ctx context.Context, appId string, allType model.AllType,
+		actorFamily string, subAllFilter model.SubAllFilter) (err error)
 	Cancel(ctx context.Context,
-		appId string) (err error)
-	GetByAppId(ctx context.Context,
-		appId string) (subAll model.UserAppSubAll, err error)
+		appId string, allType model.AllType, actorFamily string) (err error)
+	GetByAppId(ctx context.Context, appId string, allType model.AllType,
+		actorFamily string) (subAll model.UserAppSubAll, err error)
 	ListByAllType(ctx context.Context,
 		allType model.AllType) (watchAllList []*model.UserAppSubAll, err error)
 }
@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/bitrainforest/pulsar/internal/helper"
 
@@ -22,6 +23,15 @@ func (all UserAppSubAllDaoImpl) GetCollection() *mongo.Collection {
 	return GetMongoDatabase().Collection("user_app_sub_all")
 }
 
+// subAllFilter an app has at most one sub of DefaultAllType, one of HeadAllType and one sub for each actor family
+func subAllFilter(appId string, allType model.AllType, actorFamily string) bson.M {
+	filter := bson.M{"app_id": appId, "all_type": allType}
+	if allType == model.ActorAllType {
+		filter["actor_family"] = actorFamily
+	}
+	return filter
+}
+
 func (all UserAppSubAllDaoImpl) Create(ctx context.Context,
 	watchAllModel *model.UserAppSubAll) (err error) {
 	_, err = all.GetCollection().InsertOne(ctx, watchAllModel)
@@ -51,17 +61,31 @@ func (all UserAppSubAllDaoImpl) ListByAllType(ctx context.Context,
 	return
 }
 
+func (all UserAppSubAllDaoImpl) UpdateFilter(ctx context.Context, appId string, allType model.AllType,
+	actorFamily string, subAll model.SubAllFilter) (err error) {
+	filter := subAllFilter(appId, allType, actorFamily)
+	update := bson.M{"$set": bson.M{
+		"min_value":   subAll.MinValue,
+		"max_value":   subAll.MaxValue,
+		"expr":        subAll.Expr,
+		"update_time": time.Now().Unix(),
+	}}
+	_, err = all.GetCollection().UpdateOne(ctx, filter, update)
+	err = helper.WarpMongoErr(err)
+	return
+}
+
 func (all UserAppSubAllDaoImpl) Cancel(ctx context.Context,
-	appId string) (err error) {
-	filter := bson.M{"app_id": appId}
+	appId string, allType model.AllType, actorFamily string) (err error) {
+	filter := subAllFilter(appId, allType, actorFamily)
 	_, err = all.GetCollection().DeleteOne(ctx, filter)
 	err = helper.WarpMongoErr(err)
 	return
 }
 
-func (all UserAppSubAllDaoImpl) GetByAppId(ctx context.Context,
-	appId string) (subAll model.UserAppSubAll, err error) {
-	filter := bson.M{"app_id": appId}
+func (all UserAppSubAllDaoImpl) GetByAppId(ctx context.Context, appId string, allType model.AllType,
+	actorFamily string) (subAll model.UserAppSubAll, err error) {
+	filter := subAllFilter(appId, allType, actorFamily)
 	result := all.GetCollection().FindOne(ctx, filter)
 	err = helper.WarpMongoErr(result.Decode(&subAll))
 	return
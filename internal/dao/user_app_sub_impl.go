@@ -6,6 +6,8 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/filecoin-project/go-state-types/big"
+
 	"github.com/bitrainforest/pulsar/internal/helper"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -44,30 +46,44 @@ func (appWatch UserAppSubDaoImpl) FindByAddress(ctx context.Context,
 }
 
 func (appWatch UserAppSubDaoImpl) FindByAddresses(ctx context.Context,
-	address []string) (list []*model.SpecialUserAppSub, err error) {
-
-	matchStage := bson.D{{Key: "$match", Value: bson.M{"address": bson.M{"$in": address}}}}
-	groupStage := bson.D{{Key: "$group", Value: bson.D{
-		{Key: "_id", Value: bson.D{
-			{Key: "app_id", Value: "$app_id"},
-		}},
-		{Key: "app_id", Value: bson.D{
-			{Key: "$first", Value: "$app_id"},
-		}},
-	}},
-	}
-	projectStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "app_id", Value: 1},
-		}},
-	}
-	opts := options.Aggregate().SetMaxTime(15 * time.Second)
-	cur, err := appWatch.GetCollection().Aggregate(ctx,
-		mongo.Pipeline{matchStage, groupStage, projectStage}, opts)
+	from, to string) (list []*model.SpecialUserAppSub, err error) {
+	// $ne is used instead of $in, the subs created before direction existed have no direction field
+	filter := bson.M{"$or": bson.A{
+		bson.M{"address": to, "direction": bson.M{"$ne": model.OutboundDirection}},
+		bson.M{"address": from, "direction": bson.M{"$ne": model.InboundDirection}},
+	}}
+	// every subscription carries its own filter, so the subs of an app can not be grouped here,
+	// the caller is responsible for deduplicating the appIds after the filters are applied.
+	opts := options.Find().SetMaxTime(15 * time.Second).SetProjection(bson.M{
+		"_id": 0, "create_time": 0, "update_time": 0, "state": 0,
+	})
+	cur, err := appWatch.GetCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var appWatch model.SpecialUserAppSub
+		err := cur.Decode(&appWatch)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &appWatch)
+	}
+	return
+}
+
+func (appWatch UserAppSubDaoImpl) FindByKinds(ctx context.Context,
+	kinds []string) (list []*model.SpecialUserAppSub, err error) {
+	filter := bson.M{"kinds": bson.M{"$in": kinds}}
+	opts := options.Find().SetMaxTime(15 * time.Second).SetProjection(bson.M{
+		"_id": 0, "create_time": 0, "update_time": 0, "state": 0,
+	})
+	cur, err := appWatch.GetCollection().Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
+	defer cur.Close(ctx)
 	for cur.Next(ctx) {
 		var appWatch model.SpecialUserAppSub
 		err := cur.Decode(&appWatch)
@@ -89,6 +105,25 @@ func (appWatch UserAppSubDaoImpl) Create(ctx context.Context,
 	return
 }
 
+func (appWatch UserAppSubDaoImpl) UpdateFilter(ctx context.Context,
+	appId, address string, subFilter model.SubFilter) (err error) {
+	filter := bson.M{"app_id": appId, "address": address}
+	update := bson.M{"$set": bson.M{
+		"methods":          subFilter.Methods,
+		"direction":        subFilter.Direction,
+		"exit_code_policy": subFilter.ExitCodePolicy,
+		"exit_codes":       subFilter.ExitCodes,
+		"min_value":        subFilter.MinValue,
+		"max_value":        subFilter.MaxValue,
+		"expr":             subFilter.Expr,
+		"kinds":            subFilter.Kinds,
+		"update_time":      time.Now().Unix(),
+	}}
+	_, err = appWatch.GetCollection().UpdateOne(ctx, filter, update)
+	err = helper.WarpMongoErr(err)
+	return
+}
+
 func (appWatch UserAppSubDaoImpl) Cancel(ctx context.Context,
 	appId, address string) (err error) {
 	filter := bson.M{"app_id": appId, "address": address}
@@ -97,6 +132,150 @@ func (appWatch UserAppSubDaoImpl) Cancel(ctx context.Context,
 	return
 }
 
+func (appWatch UserAppSubDaoImpl) UpgradeAddress(ctx context.Context,
+	from, to string) (upgraded int64, err error) {
+	coll := appWatch.GetCollection()
+	// the apps who subscribe both addresses keep their sub of the new address,
+	// the sub of the old address is merged into it
+	appIds, err := coll.Distinct(ctx, "app_id", bson.M{"address": to})
+	if err != nil {
+		return 0, helper.WarpMongoErr(err)
+	}
+	if len(appIds) > 0 {
+		cur, err := coll.Find(ctx, bson.M{"address": from, "app_id": bson.M{"$in": appIds}})
+		if err != nil {
+			return 0, helper.WarpMongoErr(err)
+		}
+		var moved []model.UserAppSub
+		err = cur.All(ctx, &moved)
+		if err != nil {
+			return 0, err
+		}
+		for _, sub := range moved {
+			kept, err := appWatch.GetByAppId(ctx, sub.AppId, to)
+			if err != nil {
+				return upgraded, err
+			}
+			if !kept.IsEmpty() {
+				err = appWatch.UpdateFilter(ctx, sub.AppId, to, mergeSubFilter(kept.SubFilter, sub.SubFilter))
+				if err != nil {
+					return upgraded, err
+				}
+			}
+			if err = appWatch.Cancel(ctx, sub.AppId, from); err != nil {
+				return upgraded, err
+			}
+			upgraded++
+		}
+	}
+
+	filter := bson.M{"address": from}
+	update := bson.M{"$set": bson.M{
+		"address":     to,
+		"update_time": time.Now().Unix(),
+	}}
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return upgraded, helper.WarpMongoErr(err)
+	}
+	return upgraded + result.ModifiedCount, nil
+}
+
+// mergeSubFilter merge the filter of the moved sub into the filter of the kept sub of the same app.
+// The kinds of both are subscribed, the message filter matches every message either of them matches,
+// it may match more since the fields are widened one by one.
+func mergeSubFilter(kept, moved model.SubFilter) model.SubFilter {
+	keptMsg, movedMsg := kept.HasKind(model.MessageKind), moved.HasKind(model.MessageKind)
+	merged := kept
+	switch {
+	case movedMsg && !keptMsg:
+		merged = moved
+	case movedMsg && keptMsg:
+		merged = widenSubFilter(kept, moved)
+	}
+	merged.Kinds = nil
+	if len(kept.Kinds) > 0 || len(moved.Kinds) > 0 {
+		merged.Kinds = unionKinds(kept, moved)
+	}
+	return merged
+}
+
+// unionKinds the kinds subscribed by any of the filters, the default MessageKind is listed explicitly
+func unionKinds(filters ...model.SubFilter) (kinds []string) {
+	seen := make(map[string]struct{})
+	for _, filter := range filters {
+		filterKinds := filter.Kinds
+		if len(filterKinds) == 0 {
+			filterKinds = []string{model.MessageKind}
+		}
+		for _, kind := range filterKinds {
+			if _, ok := seen[kind]; !ok {
+				seen[kind] = struct{}{}
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return
+}
+
+// widenSubFilter the message filter matching the messages of both filters
+func widenSubFilter(a, b model.SubFilter) model.SubFilter {
+	merged := a
+	merged.Methods = nil
+	if len(a.Methods) > 0 && len(b.Methods) > 0 {
+		merged.Methods = append(merged.Methods, a.Methods...)
+		for _, method := range b.Methods {
+			if !a.MatchMethod(method) {
+				merged.Methods = append(merged.Methods, method)
+			}
+		}
+	}
+	if a.Direction != b.Direction {
+		merged.Direction = int8(model.BothDirection)
+	}
+	merged.ExitCodes = nil
+	switch {
+	case a.ExitCodePolicy != b.ExitCodePolicy:
+		merged.ExitCodePolicy = int8(model.AnyExitCode)
+	case model.ExitCodePolicy(a.ExitCodePolicy) == model.SpecificExitCode:
+		merged.ExitCodes = append(merged.ExitCodes, a.ExitCodes...)
+		for _, code := range b.ExitCodes {
+			if !a.MatchExitCode(code) {
+				merged.ExitCodes = append(merged.ExitCodes, code)
+			}
+		}
+	}
+	merged.MinValue = widenValue(a.MinValue, b.MinValue, big.Int.LessThan)
+	merged.MaxValue = widenValue(a.MaxValue, b.MaxValue, big.Int.GreaterThan)
+	switch {
+	case a.Expr == b.Expr:
+	case a.Expr == "" || b.Expr == "":
+		merged.Expr = ""
+	default:
+		merged.Expr = "(" + a.Expr + ") || (" + b.Expr + ")"
+	}
+	return merged
+}
+
+// widenValue the looser bound of the two, empty means no bound
+func widenValue(a, b string, looser func(x, y big.Int) bool) string {
+	if a == "" || b == "" {
+		return ""
+	}
+	x, err := big.FromString(a)
+	if err != nil {
+		return ""
+	}
+	y, err := big.FromString(b)
+	if err != nil {
+		return ""
+	}
+	if looser(y, x) {
+		return b
+	}
+	return a
+}
+
 func (appWatch UserAppSubDaoImpl) GetByAppId(ctx context.Context,
 	appId, address string) (appWatchModel model.UserAppSub, err error) {
 	filter := bson.M{"app_id": appId, "address": address}
@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+func TestMergeSubFilter(t *testing.T) {
+	// the ID sub gets the sector events, the robust sub gets the outbound transfers
+	kept := model.SubFilter{Kinds: []string{model.SectorKind}}
+	moved := model.SubFilter{
+		Methods:        []abi.MethodNum{0},
+		Direction:      int8(model.OutboundDirection),
+		ExitCodePolicy: int8(model.SuccessExitCode),
+		ValueRange:     model.ValueRange{MinValue: "100"},
+		Expr:           "value > 1fil",
+	}
+	merged := mergeSubFilter(kept, moved)
+	assert.Equal(t, []string{model.SectorKind, model.MessageKind}, merged.Kinds)
+	// only the robust sub filters the messages
+	assert.Equal(t, moved.Methods, merged.Methods)
+	assert.Equal(t, moved.Direction, merged.Direction)
+	assert.Equal(t, moved.ExitCodePolicy, merged.ExitCodePolicy)
+	assert.Equal(t, moved.ValueRange, merged.ValueRange)
+	assert.Equal(t, moved.Expr, merged.Expr)
+
+	// the robust sub only subscribes the sector events, the message filter of the ID sub is kept
+	merged = mergeSubFilter(moved, kept)
+	assert.Equal(t, []string{model.MessageKind, model.SectorKind}, merged.Kinds)
+	assert.Equal(t, moved.Methods, merged.Methods)
+	assert.Equal(t, moved.Expr, merged.Expr)
+}
+
+func TestMergeSubFilter_Widen(t *testing.T) {
+	a := model.SubFilter{
+		Methods:        []abi.MethodNum{2, 3},
+		Direction:      int8(model.InboundDirection),
+		ExitCodePolicy: int8(model.SpecificExitCode),
+		ExitCodes:      []exitcode.ExitCode{1},
+		ValueRange:     model.ValueRange{MinValue: "100", MaxValue: "1000"},
+		Expr:           "height > 10",
+	}
+	b := model.SubFilter{
+		Methods:        []abi.MethodNum{3, 4},
+		Direction:      int8(model.OutboundDirection),
+		ExitCodePolicy: int8(model.SpecificExitCode),
+		ExitCodes:      []exitcode.ExitCode{1, 2},
+		ValueRange:     model.ValueRange{MinValue: "10", MaxValue: "500"},
+		Expr:           "!is_subcall",
+	}
+	merged := mergeSubFilter(a, b)
+	assert.Nil(t, merged.Kinds)
+	assert.Equal(t, []abi.MethodNum{2, 3, 4}, merged.Methods)
+	assert.Equal(t, int8(model.BothDirection), merged.Direction)
+	assert.Equal(t, int8(model.SpecificExitCode), merged.ExitCodePolicy)
+	assert.Equal(t, []exitcode.ExitCode{1, 2}, merged.ExitCodes)
+	assert.Equal(t, model.ValueRange{MinValue: "10", MaxValue: "1000"}, merged.ValueRange)
+	assert.Equal(t, "(height > 10) || (!is_subcall)", merged.Expr)
+
+	// an unlimited field of either filter is unlimited
+	b = model.SubFilter{ExitCodePolicy: int8(model.FailureExitCode), ValueRange: model.ValueRange{MinValue: "10"}}
+	merged = mergeSubFilter(a, b)
+	assert.Nil(t, merged.Methods)
+	assert.Equal(t, int8(model.AnyExitCode), merged.ExitCodePolicy)
+	assert.Nil(t, merged.ExitCodes)
+	assert.Equal(t, model.ValueRange{MinValue: "10"}, merged.ValueRange)
+	assert.Empty(t, merged.Expr)
+	// the kept filter is not changed
+	assert.Equal(t, []abi.MethodNum{2, 3}, a.Methods)
+}
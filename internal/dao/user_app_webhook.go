@@ -0,0 +1,19 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+type UserAppWebhookDao interface {
+	Create(ctx context.Context, webhook *model.UserAppWebhook) (err error)
+	Cancel(ctx context.Context, appId, url string) (err error)
+	// DeleteByAppId delete all the webhooks of the app, e.g. the app is deleted
+	DeleteByAppId(ctx context.Context, appId string) (err error)
+	ListByAppId(ctx context.Context, appId string) (list []*model.UserAppWebhook, err error)
+	// List all the webhooks, they are loaded when the service is started
+	List(ctx context.Context) (list []*model.UserAppWebhook, err error)
+	// EnsureIndexes an endpoint is registered once by the app, the duplicate Create is ignored
+	EnsureIndexes(ctx context.Context) (err error)
+}
@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/bitrainforest/pulsar/internal/helper"
+	"github.com/bitrainforest/pulsar/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UserAppWebhookDaoImpl struct {
+}
+
+func NewUserAppWebhookDao() UserAppWebhookDao {
+	return &UserAppWebhookDaoImpl{}
+}
+
+func (webhook UserAppWebhookDaoImpl) GetCollection() *mongo.Collection {
+	return GetMongoDatabase().Collection("user_app_webhook")
+}
+
+func (webhook UserAppWebhookDaoImpl) Create(ctx context.Context, webhookModel *model.UserAppWebhook) (err error) {
+	_, err = webhook.GetCollection().InsertOne(ctx, webhookModel)
+	// the endpoint has been registered by the app
+	if err != nil && mongo.IsDuplicateKeyError(err) {
+		err = nil
+	}
+	return
+}
+
+func (webhook UserAppWebhookDaoImpl) EnsureIndexes(ctx context.Context) (err error) {
+	_, err = webhook.GetCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "app_id", Value: 1}, {Key: "url", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return
+}
+
+func (webhook UserAppWebhookDaoImpl) Cancel(ctx context.Context, appId, url string) (err error) {
+	filter := bson.M{"app_id": appId, "url": url}
+	_, err = webhook.GetCollection().DeleteOne(ctx, filter)
+	err = helper.WarpMongoErr(err)
+	return
+}
+
+func (webhook UserAppWebhookDaoImpl) DeleteByAppId(ctx context.Context, appId string) (err error) {
+	filter := bson.M{"app_id": appId}
+	_, err = webhook.GetCollection().DeleteMany(ctx, filter)
+	err = helper.WarpMongoErr(err)
+	return
+}
+
+func (webhook UserAppWebhookDaoImpl) ListByAppId(ctx context.Context,
+	appId string) (list []*model.UserAppWebhook, err error) {
+	return webhook.find(ctx, bson.M{"app_id": appId})
+}
+
+func (webhook UserAppWebhookDaoImpl) List(ctx context.Context) (list []*model.UserAppWebhook, err error) {
+	return webhook.find(ctx, bson.M{})
+}
+
+func (webhook UserAppWebhookDaoImpl) find(ctx context.Context, filter bson.M) (list []*model.UserAppWebhook, err error) {
+	cursor, err := webhook.GetCollection().Find(ctx, filter)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var webhookModel model.UserAppWebhook
+		if err = cursor.Decode(&webhookModel); err != nil {
+			return
+		}
+		list = append(list, &webhookModel)
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxDeadLetterPayloads how many skipped notifications a dead letter keeps, the later ones go to a new letter
+const MaxDeadLetterPayloads = 1000
+
+const (
+	NatsChannel      = "nats"
+	WebhookChannel   = "webhook"
+	JetStreamChannel = "jetstream"
+	StreamChannel    = "stream"
+)
+
+// DeadLetter a notification which is not delivered to the app after all the retries,
+// it's kept until the app re-drives it
+type DeadLetter struct {
+	Id    primitive.ObjectID `bson:"_id,omitempty"`
+	AppId string             `bson:"app_id"`
+	// Channel how the notification is delivered, NatsChannel, WebhookChannel, JetStreamChannel or StreamChannel
+	Channel string `bson:"channel"`
+	// Url the endpoint of the webhook, only used by WebhookChannel
+	Url string `bson:"url,omitempty"`
+	// Payload the json of the notification
+	Payload string `bson:"payload"`
+	// Payloads the notifications skipped after Payload while the endpoint is down, they're re-driven in order
+	Payloads   []string `bson:"payloads,omitempty"`
+	Err        string   `bson:"err"`
+	Attempts   int      `bson:"attempts"`
+	UpdateTime int64    `bson:"update_time"`
+	CreateTime int64    `bson:"create_time"`
+}
+
+func NewDefaultDeadLetter() DeadLetter {
+	now := time.Now().Unix()
+	return DeadLetter{
+		UpdateTime: now,
+		CreateTime: now,
+	}
+}
+
+func (letter *DeadLetter) IsEmpty() bool {
+	return letter.AppId == ""
+}
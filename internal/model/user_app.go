@@ -15,12 +15,16 @@ type (
 
 	UserApp struct {
 		// todo add userId
-		AppId      string `bson:"app_id"`
-		AppSecret  string `bson:"app_secret"`
-		AppType    int8   `bson:"app_type"`
-		State      int8   `bson:"state"`
-		UpdateTime int64  `bson:"update_time"`
-		CreateTime int64  `bson:"create_time"`
+		AppId     string `bson:"app_id"`
+		AppSecret string `bson:"app_secret"`
+		AppType   int8   `bson:"app_type"`
+		State     int8   `bson:"state"`
+		// Confirmations the app is notified only after the tipset is some epochs deep, 0 means at once
+		Confirmations int64 `bson:"confirmations"`
+		// SigningSecret signs the payloads posted to the webhooks of the app, generated when the first one is registered
+		SigningSecret string `bson:"signing_secret,omitempty"`
+		UpdateTime    int64  `bson:"update_time"`
+		CreateTime    int64  `bson:"create_time"`
 	}
 )
 
@@ -56,6 +60,12 @@ func (app *UserApp) GenAppSecret() {
 	app.AppSecret = crypt.SHA1(app.AppId)
 }
 
+// GenSigningSecret the secret is never weak, it fails if the system can't provide the random bytes
+func (app *UserApp) GenSigningSecret() (err error) {
+	app.SigningSecret, err = crypt.RandHex(32)
+	return
+}
+
 func (app *UserApp) CheckAppSecret(appSecret string) bool {
 	return app.AppSecret == appSecret
 }
@@ -1,18 +1,89 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+)
+
+type Direction int8
+
+const (
+	BothDirection     Direction = 0 // the address is either the sender or the receiver
+	InboundDirection  Direction = 1 // the address is the receiver
+	OutboundDirection Direction = 2 // the address is the sender
+)
+
+type ExitCodePolicy int8
+
+const (
+	AnyExitCode      ExitCodePolicy = 0 // the message is matched whatever the execution outcome is
+	SuccessExitCode  ExitCodePolicy = 1 // only the succeeded messages
+	FailureExitCode  ExitCodePolicy = 2 // only the failed messages
+	SpecificExitCode ExitCodePolicy = 3 // only the messages exited with one of the exit codes
+)
+
+// the kinds of the notifications of a subscribed address
+const (
+	MessageKind  = "message"  // the messages sent or received by the address, it's the default kind
+	SectorKind   = "sector"   // the sector lifecycle events of the miner
+	DealKind     = "deal"     // the storage deal events of the client or the provider
+	MultisigKind = "multisig" // the pending transaction events of the multisig or its signers
+	VerifregKind = "verifreg" // the allowance of the verifier or the DataCap of the verified client
+	PowerKind    = "power"    // the power claim of the miner, or the network totals of the power actor
+	// ActorCreatedKind the robust address gets an ID address, or all new actors if the address is the init actor
+	ActorCreatedKind = "actor_created"
+	// DeadlineKind the faulty, recovering and removed sectors of the partitions of the miner
+	DeadlineKind = "deadline"
+	// DeadlineReminderKind the Window PoSt deadline of the miner is opened or about to close
+	DeadlineReminderKind = "deadline_reminder"
+	// BalanceKind the balance of the actor is changed, whatever the cause is
+	BalanceKind = "balance"
+	// PendingKind the messages of the address enter or leave the mpool, before they are applied
+	PendingKind = "pending"
+)
 
 type UserAppSub struct {
 	AppId      string `bson:"app_id"`
 	Address    string `bson:"address"`
-	UpdateTime int64  `bson:"update_time"`
-	CreateTime int64  `bson:"create_time"`
-	State      int8   `bson:"state"`
+	SubFilter  `bson:",inline"`
+	UpdateTime int64 `bson:"update_time"`
+	CreateTime int64 `bson:"create_time"`
+	State      int8  `bson:"state"`
 }
 
 type SpecialUserAppSub struct {
-	AppId   string `bson:"app_id"`
-	Address string `bson:"address"`
+	AppId     string `bson:"app_id"`
+	Address   string `bson:"address"`
+	SubFilter `bson:",inline"`
+}
+
+// SubFilter narrows down the messages of a subscribed address that are sent to the app,
+// the zero value matches every message.
+type SubFilter struct {
+	// Methods only the messages calling one of the methods are matched, empty means all methods
+	Methods []abi.MethodNum `bson:"methods,omitempty"`
+	// Direction which side of the message the address must be on, see BothDirection
+	Direction int8 `bson:"direction"`
+	// ExitCodePolicy which execution outcome the message must have, see AnyExitCode
+	ExitCodePolicy int8 `bson:"exit_code_policy"`
+	// ExitCodes only used by the SpecificExitCode policy
+	ExitCodes  []exitcode.ExitCode `bson:"exit_codes,omitempty"`
+	ValueRange `bson:",inline"`
+	// Expr the filter expression the message must match, empty means no expression, see filterexpr
+	Expr string `bson:"expr,omitempty"`
+	// Kinds which kinds of notifications of the address are sent, empty means only MessageKind,
+	// the filter above only applies to the messages
+	Kinds []string `bson:"kinds,omitempty"`
+}
+
+// ValueRange the value of the message must be in [MinValue, MaxValue],
+// both are attoFIL in decimal and empty means no limit.
+type ValueRange struct {
+	MinValue string `bson:"min_value,omitempty"`
+	MaxValue string `bson:"max_value,omitempty"`
 }
 
 func NewDefaultAppSub() UserAppSub {
@@ -27,3 +98,72 @@ func NewDefaultAppSub() UserAppSub {
 func (watch *UserAppSub) IsEmpty() bool {
 	return watch.AppId == ""
 }
+
+// HasKind whether the kind of notifications is subscribed
+func (filter *SubFilter) HasKind(kind string) bool {
+	if len(filter.Kinds) == 0 {
+		return kind == MessageKind
+	}
+	for _, k := range filter.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter *SubFilter) MatchMethod(method abi.MethodNum) bool {
+	if len(filter.Methods) == 0 {
+		return true
+	}
+	for _, m := range filter.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter *SubFilter) MatchExitCode(code exitcode.ExitCode) bool {
+	switch ExitCodePolicy(filter.ExitCodePolicy) {
+	case SuccessExitCode:
+		return code.IsSuccess()
+	case FailureExitCode:
+		return code.IsError()
+	case SpecificExitCode:
+		for _, c := range filter.ExitCodes {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func (r *ValueRange) IsEmpty() bool {
+	return r.MinValue == "" && r.MaxValue == ""
+}
+
+func (r *ValueRange) MatchValue(value abi.TokenAmount) bool {
+	if r.IsEmpty() {
+		return true
+	}
+	if value.Int == nil {
+		value = big.Zero()
+	}
+	if r.MinValue != "" {
+		min, err := big.FromString(r.MinValue)
+		if err == nil && value.LessThan(min) {
+			return false
+		}
+	}
+	if r.MaxValue != "" {
+		max, err := big.FromString(r.MaxValue)
+		if err == nil && value.GreaterThan(max) {
+			return false
+		}
+	}
+	return true
+}
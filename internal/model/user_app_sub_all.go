@@ -6,13 +6,25 @@ type AllType int8
 
 const (
 	DefaultAllType AllType = 1 //all addresses of  all methods
+	ActorAllType   AllType = 2 //all addresses of the actor family, e.g. storageminer
+	HeadAllType    AllType = 3 //one notification for each new head of the chain
 )
 
 type UserAppSubAll struct {
-	AppId      string `bson:"app_id"`
-	AllType    int8   `bson:"all_type"`
-	UpdateTime int64  `bson:"update_time"`
-	CreateTime int64  `bson:"create_time"`
+	AppId   string `bson:"app_id"`
+	AllType int8   `bson:"all_type"`
+	// ActorFamily only used by ActorAllType, it's the family of the actor code, see builtin.ActorFamily
+	ActorFamily  string `bson:"actor_family,omitempty"`
+	SubAllFilter `bson:",inline"`
+	UpdateTime   int64 `bson:"update_time"`
+	CreateTime   int64 `bson:"create_time"`
+}
+
+// SubAllFilter narrows down the messages of a subAll that are sent to the app
+type SubAllFilter struct {
+	ValueRange `bson:",inline"`
+	// Expr the filter expression the message must match, empty means no expression, see filterexpr
+	Expr string `bson:"expr,omitempty"`
 }
 
 func NewDefaultAppSubAll() UserAppSubAll {
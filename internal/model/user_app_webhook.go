@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// MaxAppWebhooks the endpoints an app can register at most
+const MaxAppWebhooks = 5
+
+// UserAppWebhook an HTTPS endpoint the notifications of the app are posted to,
+// they are signed by the signing secret of the app
+type UserAppWebhook struct {
+	AppId      string `bson:"app_id"`
+	Url        string `bson:"url"`
+	UpdateTime int64  `bson:"update_time"`
+	CreateTime int64  `bson:"create_time"`
+}
+
+func NewDefaultAppWebhook() UserAppWebhook {
+	now := time.Now().Unix()
+	return UserAppWebhook{
+		UpdateTime: now,
+		CreateTime: now,
+	}
+}
+
+func (webhook *UserAppWebhook) IsEmpty() bool {
+	return webhook.AppId == ""
+}
@@ -12,19 +12,38 @@ import (
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
 )
 
 type ActorAddress struct {
 	cs *store.ChainStore
 	m  sync.Map
+	// codes the codes of the actors, they're reloaded after the network upgrades
+	codes *codeCache
 }
 
 func NewActorAddress(cs *store.ChainStore) *ActorAddress {
 	return &ActorAddress{
-		cs: cs, m: sync.Map{},
+		cs: cs, m: sync.Map{}, codes: newCodeCache(),
 	}
 }
 
+func (actor *ActorAddress) GetActorCode(ctx context.Context, next *types.TipSet,
+	a address.Address) (cid.Cid, error) {
+	return actor.codes.get(next.Height(), a, func() (cid.Cid, error) {
+		adtStore := actor.cs.ActorStore(ctx)
+		nextStateTree, err := state.LoadStateTree(adtStore, next.ParentState())
+		if err != nil {
+			return cid.Undef, fmt.Errorf("load state tree: %w", err)
+		}
+		act, err := nextStateTree.GetActor(a)
+		if err != nil {
+			return cid.Undef, fmt.Errorf("getting actor %v: %w", a.String(), err)
+		}
+		return act.Code, nil
+	})
+}
+
 func (actor *ActorAddress) GetActorAddress(ctx context.Context, next *types.TipSet,
 	a address.Address) (address.Address, error) {
 	c, ok := actor.m.Load(a)
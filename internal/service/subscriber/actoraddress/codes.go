@@ -0,0 +1,47 @@
+package actoraddress
+
+import (
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/ipfs/go-cid"
+)
+
+type (
+	// codeKey the codes of the builtin actors change at the network upgrades,
+	// so the code of an address is cached per network version
+	codeKey struct {
+		addr    address.Address
+		version network.Version
+	}
+
+	codeCache struct {
+		schedule stmgr.UpgradeSchedule
+		codes    sync.Map
+	}
+)
+
+func newCodeCache() *codeCache {
+	return &codeCache{schedule: filcns.DefaultUpgradeSchedule()}
+}
+
+// get the code of the address at the height, fetch is called once for each network version
+func (c *codeCache) get(height abi.ChainEpoch, a address.Address,
+	fetch func() (cid.Cid, error)) (cid.Cid, error) {
+	// the epochs before the first upgrade have no version defined, they're network.Version0
+	version, _ := c.schedule.GetNtwkVersion(height)
+	key := codeKey{addr: a, version: version}
+	if code, ok := c.codes.Load(key); ok {
+		return code.(cid.Cid), nil
+	}
+	code, err := fetch()
+	if err != nil {
+		return cid.Undef, err
+	}
+	c.codes.Store(key, code)
+	return code, nil
+}
@@ -0,0 +1,51 @@
+package actoraddress
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeCache_Upgrade(t *testing.T) {
+	a, err := address.NewIDAddress(1000)
+	assert.Nil(t, err)
+	v6Code, err := abi.CidBuilder.Sum([]byte("fil/6/storageminer"))
+	assert.Nil(t, err)
+	v7Code, err := abi.CidBuilder.Sum([]byte("fil/7/storageminer"))
+	assert.Nil(t, err)
+
+	codes := &codeCache{schedule: stmgr.UpgradeSchedule{
+		{Height: -1, Network: network.Version14},
+		{Height: 100, Network: network.Version15},
+	}}
+	fetches := 0
+	fetch := func(code cid.Cid) func() (cid.Cid, error) {
+		return func() (cid.Cid, error) {
+			fetches++
+			return code, nil
+		}
+	}
+
+	code, err := codes.get(50, a, fetch(v6Code))
+	assert.Nil(t, err)
+	assert.Equal(t, v6Code, code)
+	// cached before the upgrade
+	code, err = codes.get(100, a, fetch(v7Code))
+	assert.Nil(t, err)
+	assert.Equal(t, v6Code, code)
+	assert.Equal(t, 1, fetches)
+
+	// the code of the same address is reloaded after the upgrade
+	code, err = codes.get(101, a, fetch(v7Code))
+	assert.Nil(t, err)
+	assert.Equal(t, v7Code, code)
+	code, err = codes.get(200, a, fetch(v6Code))
+	assert.Nil(t, err)
+	assert.Equal(t, v7Code, code)
+	assert.Equal(t, 2, fetches)
+}
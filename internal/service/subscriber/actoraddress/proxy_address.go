@@ -11,6 +11,7 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
 )
 
 var (
@@ -38,6 +39,15 @@ type (
 		} `json:"error"`
 		Result string `json:"result"`
 	}
+	ActorResp struct {
+		Jsonrpc string `json:"jsonrpc"`
+		Id      int    `json:"id"`
+		Error   struct {
+			Code int64  `json:"code"`
+			Msg  string `json:"message"`
+		} `json:"error"`
+		Result *types.Actor `json:"result"`
+	}
 )
 
 type (
@@ -45,6 +55,7 @@ type (
 		client  *resty.Client
 		uri     string
 		netWork address.Network
+		codes   *codeCache
 	}
 )
 
@@ -53,6 +64,7 @@ func NewProxyActorAddress() *ProxyActorAddress {
 		client:  httpclient.NewDefaultHttpClient(),
 		uri:     fmt.Sprintf(proxyUri, MainHost),
 		netWork: address.Mainnet,
+		codes:   newCodeCache(),
 	}
 	netWork := address.CurrentNetwork
 	if netWork == address.Testnet {
@@ -88,3 +100,43 @@ func (p *ProxyActorAddress) GetActorAddress(ctx context.Context, next *types.Tip
 	// return the original address
 	return a, nil
 }
+
+func (p *ProxyActorAddress) GetActorCode(ctx context.Context, next *types.TipSet,
+	a address.Address) (cid.Cid, error) {
+	// the code at the head isn't cached, the version of the head is unknown
+	if next == nil {
+		return p.getActorCode(a, types.EmptyTSK)
+	}
+	return p.codes.get(next.Height(), a, func() (cid.Cid, error) {
+		return p.getActorCode(a, next.Key())
+	})
+}
+
+func (p *ProxyActorAddress) getActorCode(a address.Address, tsk types.TipSetKey) (cid.Cid, error) {
+	var (
+		req  Param
+		resp ActorResp
+	)
+	req.Method = "Filecoin.StateGetActor"
+	req.Jsonrpc = "2.0"
+	req.Id = 0
+	req.Params = append(req.Params, a.String())
+	if tsk == types.EmptyTSK {
+		req.Params = append(req.Params, []interface{}{})
+	} else {
+		req.Params = append(req.Params, tsk)
+	}
+	_, err := p.client.R().SetHeader("Content-Type", "application/json").
+		SetBody(req).SetResult(&resp).Post(p.uri)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if resp.Error.Code != 0 {
+		log.Errorf("get actor code error: %+v", resp.Error)
+		return cid.Undef, fmt.Errorf("address:%v err:%v", a.String(), resp.Error.Msg)
+	}
+	if resp.Result == nil {
+		return cid.Undef, fmt.Errorf("address:%v actor not found", a.String())
+	}
+	return resp.Result.Code, nil
+}
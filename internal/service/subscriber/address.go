@@ -5,9 +5,13 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
 )
 
 type Address interface {
 	GetActorAddress(ctx context.Context, next *types.TipSet,
 		a address.Address) (address.Address, error)
+	// GetActorCode get the code of the actor, it returns an error if the actor does not exist
+	GetActorCode(ctx context.Context, next *types.TipSet,
+		a address.Address) (cid.Cid, error)
 }
@@ -0,0 +1,158 @@
+package subscriber
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/filmeta-hic/model"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/bitrainforest/pulsar/internal/cache"
+)
+
+// MaxConfirmations the finality, the tipsets deeper than it are never reverted
+const MaxConfirmations = 900
+
+var _ model.NotifyMessage = rawMessage(nil)
+
+type (
+	// rawMessage the notification held by the buffer, it's published as it is
+	rawMessage []byte
+
+	unconfirmedTipSet struct {
+		height   abi.ChainEpoch
+		notifies []cache.Unconfirmed
+	}
+
+	// confirmBuffer holds the notifications of the apps who want them only after the tipsets
+	// are some epochs deep, they are released as the head advances and dropped if the tipsets are reverted.
+	// they're kept in the store as well, so that the ones held before a restart are loaded again
+	confirmBuffer struct {
+		lock    sync.Mutex
+		tipsets map[types.TipSetKey]*unconfirmedTipSet
+		store   cache.UnconfirmedStore
+	}
+)
+
+func (m rawMessage) Get() ([]byte, error) {
+	return m, nil
+}
+
+func newConfirmBuffer(store cache.UnconfirmedStore) *confirmBuffer {
+	return &confirmBuffer{
+		tipsets: make(map[types.TipSetKey]*unconfirmedTipSet),
+		store:   store,
+	}
+}
+
+// Load the notifications held before the restart
+func (buf *confirmBuffer) Load(ctx context.Context) (int, error) {
+	list, err := buf.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+	for _, item := range list {
+		buf.add(item)
+	}
+	return len(list), nil
+}
+
+// Add hold the notification of the tipset, record is journaled when it's released
+func (buf *confirmBuffer) Add(ctx context.Context, ts *types.TipSet, confirmations abi.ChainEpoch, appIds []string,
+	msg model.NotifyMessage, record *cache.Delivery) error {
+	payload, err := msg.Get()
+	if err != nil {
+		return err
+	}
+	item := cache.Unconfirmed{
+		Id:            uuid.NewV4().String(),
+		Height:        ts.Height(),
+		TipSet:        ts.Key(),
+		Confirmations: confirmations,
+		AppIds:        appIds,
+		Payload:       payload,
+		Delivery:      record,
+	}
+	buf.lock.Lock()
+	buf.add(item)
+	buf.lock.Unlock()
+	// it's still released by this instance if the store fails, only a restart loses it
+	return buf.store.Save(ctx, item)
+}
+
+func (buf *confirmBuffer) add(item cache.Unconfirmed) {
+	pending, ok := buf.tipsets[item.TipSet]
+	if !ok {
+		pending = &unconfirmedTipSet{height: item.Height}
+		buf.tipsets[item.TipSet] = pending
+	}
+	pending.notifies = append(pending.notifies, item)
+}
+
+// Release remove the notifications which are confirmed by the head, the rest are kept,
+// the notifications of the lower tipsets are released first.
+// the ones released by another instance after a restart are skipped
+func (buf *confirmBuffer) Release(ctx context.Context, head abi.ChainEpoch) (released []cache.Unconfirmed) {
+	for _, item := range buf.confirmed(head) {
+		ok, err := buf.store.Remove(ctx, item.Id)
+		if err != nil {
+			log.Errorf("[confirmBuffer] remove %v of %v err:%v", item.Id, item.TipSet, err)
+		} else if !ok {
+			continue
+		}
+		released = append(released, item)
+	}
+	return
+}
+
+func (buf *confirmBuffer) confirmed(head abi.ChainEpoch) (confirmed []cache.Unconfirmed) {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+	tsks := make([]types.TipSetKey, 0, len(buf.tipsets))
+	for tsk := range buf.tipsets {
+		tsks = append(tsks, tsk)
+	}
+	sort.Slice(tsks, func(i, j int) bool {
+		return buf.tipsets[tsks[i]].height < buf.tipsets[tsks[j]].height
+	})
+	for _, tsk := range tsks {
+		pending := buf.tipsets[tsk]
+		var rest []cache.Unconfirmed
+		for _, item := range pending.notifies {
+			if head-pending.height >= item.Confirmations {
+				confirmed = append(confirmed, item)
+			} else {
+				rest = append(rest, item)
+			}
+		}
+		if len(rest) == 0 {
+			delete(buf.tipsets, tsk)
+			continue
+		}
+		pending.notifies = rest
+	}
+	return
+}
+
+// Drop remove the notifications of the reverted tipset, return how many are dropped
+func (buf *confirmBuffer) Drop(ctx context.Context, tsk types.TipSetKey) int {
+	buf.lock.Lock()
+	pending, ok := buf.tipsets[tsk]
+	delete(buf.tipsets, tsk)
+	buf.lock.Unlock()
+	if !ok {
+		return 0
+	}
+	for _, item := range pending.notifies {
+		if _, err := buf.store.Remove(ctx, item.Id); err != nil {
+			log.Errorf("[confirmBuffer] remove %v of %v err:%v", item.Id, tsk, err)
+		}
+	}
+	return len(pending.notifies)
+}
@@ -0,0 +1,128 @@
+package subscriber
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/internal/cache"
+)
+
+var _ cache.UnconfirmedStore = &MockUnconfirmedStore{}
+
+// MockUnconfirmedStore keeps the held notifications in memory, it's shared by the buffers like the redis hash
+type MockUnconfirmedStore struct {
+	lock  sync.Mutex
+	items map[string]cache.Unconfirmed
+}
+
+func NewMockUnconfirmedStore() *MockUnconfirmedStore {
+	return &MockUnconfirmedStore{items: make(map[string]cache.Unconfirmed)}
+}
+
+func (m *MockUnconfirmedStore) Save(ctx context.Context, item cache.Unconfirmed) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items[item.Id] = item
+	return nil
+}
+
+func (m *MockUnconfirmedStore) Remove(ctx context.Context, id string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, ok := m.items[id]
+	delete(m.items, id)
+	return ok, nil
+}
+
+func (m *MockUnconfirmedStore) List(ctx context.Context) ([]cache.Unconfirmed, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	list := make([]cache.Unconfirmed, 0, len(m.items))
+	for _, item := range m.items {
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func appIdsOf(list []cache.Unconfirmed) (appIds []string) {
+	for _, item := range list {
+		appIds = append(appIds, item.AppIds...)
+	}
+	return
+}
+
+func TestConfirmBuffer(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockUnconfirmedStore()
+	buf := newConfirmBuffer(store)
+	parent := mock.TipSet(mock.MkBlock(nil, 1, 10))
+	child := mock.TipSet(mock.MkBlock(parent, 1, 11))
+	assert.Nil(t, buf.Add(ctx, parent, 5, []string{"app1"}, &MessagePayload{}, nil))
+	assert.Nil(t, buf.Add(ctx, parent, 900, []string{"app2"}, &MessagePayload{}, nil))
+	assert.Nil(t, buf.Add(ctx, child, 5, []string{"app3"}, &MessagePayload{}, nil))
+	assert.Len(t, store.items, 3)
+
+	// not deep enough
+	assert.Nil(t, appIdsOf(buf.Release(ctx, parent.Height()+4)))
+	assert.Equal(t, []string{"app1"}, appIdsOf(buf.Release(ctx, parent.Height()+5)))
+	assert.Nil(t, appIdsOf(buf.Release(ctx, parent.Height()+5)))
+
+	// app3 never gets the notification of the reverted tipset
+	assert.Equal(t, 1, buf.Drop(ctx, child.Key()))
+	assert.Equal(t, 0, buf.Drop(ctx, types.EmptyTSK))
+	assert.Nil(t, appIdsOf(buf.Release(ctx, child.Height()+5)))
+
+	assert.Equal(t, []string{"app2"}, appIdsOf(buf.Release(ctx, parent.Height()+900)))
+	assert.Empty(t, buf.tipsets)
+	assert.Empty(t, store.items)
+}
+
+func TestConfirmBuffer_Load(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockUnconfirmedStore()
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 10))
+	record := &cache.Delivery{Kind: "test"}
+	assert.Nil(t, newConfirmBuffer(store).Add(ctx, ts, 5, []string{"app1"}, &MessagePayload{}, record))
+
+	// the buffer after the restart loads the notification
+	restarted, other := newConfirmBuffer(store), newConfirmBuffer(store)
+	loaded, err := restarted.Load(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, loaded)
+	_, err = other.Load(ctx)
+	assert.Nil(t, err)
+
+	released := restarted.Release(ctx, ts.Height()+5)
+	assert.Equal(t, []string{"app1"}, appIdsOf(released))
+	assert.Equal(t, ts.Key(), released[0].TipSet)
+	assert.Equal(t, record, released[0].Delivery)
+	// it's released by one instance only
+	assert.Nil(t, other.Release(ctx, ts.Height()+5))
+	assert.Empty(t, other.tipsets)
+}
+
+func TestSubscriber_ReleaseConfirmed(t *testing.T) {
+	ctx := context.Background()
+	m := &MockNotify{}
+	journal := NewMockDeliveryJournal()
+	sub, err := NewSub([]string{"app1"}, m, WithDeliveryJournal(journal),
+		WithUnconfirmedStore(NewMockUnconfirmedStore()))
+	assert.Nil(t, err)
+	sub.SetConfirmations("app1", 5)
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 10))
+	record := &cache.Delivery{Kind: "test"}
+	delivered, err := sub.deliver(ctx, ts, []string{"app1"}, &MessagePayload{}, record)
+	assert.Nil(t, err)
+	assert.Empty(t, delivered)
+	assert.Empty(t, journal.deliveries[ts.Key()])
+
+	// the released notification is journaled, so that the app is told if the tipset is reverted later
+	sub.ReleaseConfirmed(ctx, ts.Height()+5)
+	assert.Equal(t, []cache.Delivery{{Kind: "test", AppIds: []string{"app1"}}}, journal.deliveries[ts.Key()])
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/bitrainforest/pulsar/internal/service/subscriber/actoraddress"
+	"github.com/bitrainforest/pulsar/internal/service/subscriber/statechange"
 
 	"github.com/panjf2000/ants/v2"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/store"
 
 	"github.com/bitrainforest/filmeta-hic/core/log"
@@ -17,6 +19,7 @@ import (
 	"github.com/bitrainforest/filmeta-hic/model"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
+	adt0 "github.com/filecoin-project/specs-actors/actors/util/adt"
 	"github.com/ipfs/go-cid"
 	"github.com/smallnest/chanx"
 )
@@ -46,6 +49,7 @@ type Core struct {
 	msgDone     chan struct{}
 	lock        sync.RWMutex
 	sub         *Subscriber
+	cs          *store.ChainStore
 	ch          *chanx.UnboundedChan
 	lockWait    sync.WaitGroup
 	processWait sync.WaitGroup
@@ -80,9 +84,53 @@ func NewCore(sub *Subscriber, opts ...CoreOpt) *Core {
 func (core *Core) OverrideExecMonitor(cs *store.ChainStore) *Core {
 	actor := actoraddress.NewActorAddress(cs)
 	core.sub.opts.actorAddress = actor
+	core.sub.opts.chainStore = cs
+	// the state changes of the applied tipsets are published to the apps
+	core.cs = cs
+	cs.SubscribeHeadChanges(core.HeadChange)
 	return core
 }
 
+// revertedTipSet a tipset reverted from the chain by a reorg
+type revertedTipSet struct {
+	*types.TipSet
+}
+
+// MpoolUpdate receive the messages entering or leaving the mpool
+func (core *Core) MpoolUpdate(update api.MpoolUpdate) {
+	if core.IsClosed() {
+		return
+	}
+	core.lockWait.Add(1)
+	defer core.lockWait.Done()
+	core.ch.In <- update
+}
+
+// SubscribeMpool publish the pending messages of the mpool until the updates are closed
+func (core *Core) SubscribeMpool(updates <-chan api.MpoolUpdate) {
+	threading.GoSafe(func() {
+		for update := range updates {
+			core.MpoolUpdate(update)
+		}
+	})
+}
+
+// HeadChange receive the tipsets reverted from and applied to the chain, the applied are from the oldest to the newest
+func (core *Core) HeadChange(rev, app []*types.TipSet) error {
+	if core.IsClosed() {
+		return nil
+	}
+	core.lockWait.Add(1)
+	defer core.lockWait.Done()
+	for _, ts := range rev {
+		core.ch.In <- revertedTipSet{TipSet: ts}
+	}
+	for _, ts := range app {
+		core.ch.In <- ts
+	}
+	return nil
+}
+
 func (core *Core) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
 	if core.IsClosed() {
 		return ErrClosed
@@ -109,13 +157,25 @@ func (core *Core) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid
 
 func (core *Core) Rec() {
 	for item := range core.ch.Out {
-		msg, ok := item.(*model.Message)
-		if !ok {
+		switch v := item.(type) {
+		case *model.Message:
+			if err := core.processing(v); err != nil {
+				log.Errorf("[core.Rec()] processing msg:+%v,err:%v", v, err)
+			}
+		case *types.TipSet:
+			if err := core.processingTipSet(v); err != nil {
+				log.Errorf("[core.Rec()] processing tipset:%v,err:%v", v.Key(), err)
+			}
+		case api.MpoolUpdate:
+			if err := core.processingPending(v); err != nil {
+				log.Errorf("[core.Rec()] processing pending msg:%v,err:%v", v.Message.Cid(), err)
+			}
+		case revertedTipSet:
+			if err := core.processingReverted(v.TipSet); err != nil {
+				log.Errorf("[core.Rec()] processing reverted tipset:%v,err:%v", v.Key(), err)
+			}
+		default:
 			log.Errorf("[core.Rec()] msg:%v", item)
-			continue
-		}
-		if err := core.processing(msg); err != nil {
-			log.Errorf("[core.Rec()] processing msg:+%v,err:%v", msg, err)
 		}
 	}
 	core.msgDone <- struct{}{}
@@ -124,13 +184,134 @@ func (core *Core) Rec() {
 func (core *Core) processing(msg *model.Message) error {
 	core.processWait.Add(1)
 	ctx := context.Background()
-	return core.processPool.Submit(func() {
+	// the deliveries of the tipset are in progress since it's received, a later revert waits for them
+	done := core.sub.startWork(msg.TipSet)
+	err := core.processPool.Submit(func() {
 		defer core.processWait.Done()
+		defer done()
 		err := core.sub.Notify(ctx, msg)
 		if err != nil {
 			log.Errorf("[processing] sub.Notify err:%v", err)
 		}
 	})
+	if err != nil {
+		done()
+	}
+	return err
+}
+
+// processingTipSet publish the state change made by executing the parent of the tipset
+func (core *Core) processingTipSet(ts *types.TipSet) error {
+	core.processWait.Add(1)
+	ctx := context.Background()
+	// a later revert of the tipset waits for its state change and head deliveries
+	done := core.sub.startWork(ts)
+	err := core.processPool.Submit(func() {
+		defer core.processWait.Done()
+		defer done()
+		// the head advances, the buffered notifications may be confirmed
+		core.sub.ReleaseConfirmed(ctx, ts.Height())
+		if core.sub.ExistSubHead() {
+			if err := core.sub.NotifyHead(ctx, ts, core.newHead(ctx, ts)); err != nil {
+				log.Errorf("[processingTipSet] sub.NotifyHead err:%v", err)
+			}
+		}
+		parent, err := core.cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			log.Errorf("[processingTipSet] load parent of %v err:%v", ts.Key(), err)
+			return
+		}
+		if parent.ParentState().Equals(ts.ParentState()) {
+			return
+		}
+		change, err := statechange.NewChange(core.cs.ActorStore(ctx), parent, ts)
+		if err != nil {
+			log.Errorf("[processingTipSet] state change of %v err:%v", ts.Key(), err)
+			return
+		}
+		if err = core.sub.NotifyStateChange(ctx, change); err != nil {
+			log.Errorf("[processingTipSet] sub.NotifyStateChange err:%v", err)
+		}
+	})
+	if err != nil {
+		done()
+	}
+	return err
+}
+
+// processingPending publish the message entering or leaving the mpool, the addresses are resolved at the head
+func (core *Core) processingPending(update api.MpoolUpdate) error {
+	core.processWait.Add(1)
+	ctx := context.Background()
+	return core.processPool.Submit(func() {
+		defer core.processWait.Done()
+		status := PendingStatus
+		if update.Type == api.MpoolRemove {
+			status = RemovedStatus
+		}
+		msg := update.Message.Message
+		from, to := core.sub.GetActorAddress(ctx, msg.From, msg.To, core.cs.GetHeaviestTipSet())
+		if err := core.sub.NotifyPending(ctx, status, update.Message, from, to); err != nil {
+			log.Errorf("[processingPending] sub.NotifyPending err:%v", err)
+		}
+	})
+}
+
+// processingReverted tell the apps the messages delivered from the reverted tipset
+func (core *Core) processingReverted(ts *types.TipSet) error {
+	core.processWait.Add(1)
+	ctx := context.Background()
+	return core.processPool.Submit(func() {
+		defer core.processWait.Done()
+		// the tipset is applied before it's reverted, its deliveries are journaled and buffered first
+		core.sub.WaitTipSet(ts.Key())
+		core.sub.DropUnconfirmed(ctx, ts.Key())
+		if err := core.sub.NotifyReverted(ctx, ts); err != nil {
+			log.Errorf("[processingReverted] sub.NotifyReverted err:%v", err)
+		}
+	})
+}
+
+// executedMessages load the messages of parent with their receipts, which are kept by the blocks of ts
+func (core *Core) executedMessages(parent, ts *types.TipSet) statechange.MessageLoader {
+	return func(ctx context.Context) ([]statechange.ExecutedMessage, error) {
+		msgs, err := core.cs.MessagesForTipset(ctx, parent)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load messages of %v", parent.Key())
+		}
+		// block headers use adt0
+		receipts, err := adt0.AsArray(core.cs.ActorStore(ctx), ts.Blocks()[0].ParentMessageReceipts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load receipts of %v", ts.Key())
+		}
+		executed := make([]statechange.ExecutedMessage, 0, len(msgs))
+		for i, msg := range msgs {
+			var receipt types.MessageReceipt
+			found, err := receipts.Get(uint64(i), &receipt)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get receipt %d of %v", i, ts.Key())
+			}
+			if !found {
+				return nil, errors.Errorf("receipt %d of %v not found", i, ts.Key())
+			}
+			executed = append(executed, statechange.ExecutedMessage{Message: msg.VMMessage(), Receipt: receipt})
+		}
+		return executed, nil
+	}
+}
+
+// newHead build the head payload, the message counts are zero if the messages of a block can not be read
+func (core *Core) newHead(ctx context.Context, ts *types.TipSet) *HeadPayload {
+	blockMsgCids := make([][]cid.Cid, 0, len(ts.Blocks()))
+	for _, blk := range ts.Blocks() {
+		blsCids, secpkCids, err := core.cs.ReadMsgMetaCids(ctx, blk.Messages)
+		if err != nil {
+			log.Errorf("[newHead] read messages of block %v err:%v", blk.Cid(), err)
+			return NewHeadPayload(ts, nil)
+		}
+		blockMsgCids = append(blockMsgCids, append(blsCids, secpkCids...))
+	}
+	return NewHeadPayload(ts, blockMsgCids)
 }
 
 func (core *Core) IsClosed() bool {
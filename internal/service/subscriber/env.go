@@ -0,0 +1,96 @@
+package subscriber
+
+import (
+	"context"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/internal/service/subscriber/filterexpr"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+)
+
+// MsgEnv is the message to be published, it provides the fields of the filter expressions.
+// The actor addresses and the actor code of the receiver are loaded at most once and only when needed,
+// it must not be used concurrently.
+type MsgEnv struct {
+	ctx       context.Context
+	sub       *Subscriber
+	Msg       TraceMsg
+	TipSet    *types.TipSet
+	Implicit  bool
+	IsSubCall bool
+
+	resolved bool
+	from, to address.Address
+
+	codeLoaded bool
+	code       cid.Cid
+	codeErr    error
+}
+
+func NewMsgEnv(ctx context.Context, sub *Subscriber, msg TraceMsg, tipSet *types.TipSet,
+	implicit, isSubCall bool) *MsgEnv {
+	return &MsgEnv{ctx: ctx, sub: sub, Msg: msg, TipSet: tipSet, Implicit: implicit, IsSubCall: isSubCall}
+}
+
+// Addresses get the actor (ID) addresses of the sender and the receiver
+func (env *MsgEnv) Addresses() (from, to address.Address) {
+	if !env.resolved {
+		env.from, env.to = env.sub.GetActorAddress(env.ctx, env.Msg.From, env.Msg.To, env.TipSet)
+		env.resolved = true
+	}
+	return env.from, env.to
+}
+
+// ActorCode get the code of the receiver, the receiver may be created by the message itself
+func (env *MsgEnv) ActorCode() (cid.Cid, error) {
+	if !env.codeLoaded {
+		_, to := env.Addresses()
+		env.code, env.codeErr = env.sub.opts.actorAddress.GetActorCode(env.ctx, env.TipSet, to)
+		env.codeLoaded = true
+	}
+	return env.code, env.codeErr
+}
+
+// ActorFamily get the family of the receiver, it's empty if the code of the receiver is unknown
+func (env *MsgEnv) ActorFamily() string {
+	code, err := env.ActorCode()
+	if err != nil {
+		log.Warnf("[MsgEnv] get actor code of %v err:%v", env.Msg.To.String(), err)
+		return ""
+	}
+	return builtin.ActorFamily(builtin.ActorNameByCode(code))
+}
+
+func (env *MsgEnv) Get(field string) interface{} {
+	switch field {
+	case filterexpr.FieldFrom:
+		from, _ := env.Addresses()
+		return from.String()
+	case filterexpr.FieldTo:
+		_, to := env.Addresses()
+		return to.String()
+	case filterexpr.FieldMethod:
+		return env.Msg.Method
+	case filterexpr.FieldValue:
+		return env.Msg.Value
+	case filterexpr.FieldExitCode:
+		return env.Msg.Receipt.ExitCode
+	case filterexpr.FieldActorFamily:
+		return env.ActorFamily()
+	case filterexpr.FieldGasUsed:
+		return env.Msg.Receipt.GasUsed
+	case filterexpr.FieldHeight:
+		if env.TipSet == nil {
+			return 0
+		}
+		return env.TipSet.Height()
+	case filterexpr.FieldIsSubCall:
+		return env.IsSubCall
+	case filterexpr.FieldImplicit:
+		return env.Implicit
+	}
+	return nil
+}
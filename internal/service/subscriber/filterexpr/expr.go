@@ -0,0 +1,141 @@
+// Package filterexpr implements the filter expression an app can attach to a
+// subscription, e.g.
+//
+//	method in [2, 3] && value >= 10fil && !is_subcall
+//
+// An expression is compiled once and then evaluated against every message.
+package filterexpr
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	fbig "github.com/filecoin-project/go-state-types/big"
+)
+
+// The fields which can be used in an expression.
+const (
+	FieldFrom        = "from"
+	FieldTo          = "to"
+	FieldMethod      = "method"
+	FieldValue       = "value"
+	FieldExitCode    = "exit_code"
+	FieldActorFamily = "actor_family"
+	FieldGasUsed     = "gas_used"
+	FieldHeight      = "height"
+	FieldIsSubCall   = "is_subcall"
+	FieldImplicit    = "implicit"
+)
+
+var fields = map[string]kind{
+	FieldFrom:        kindString,
+	FieldTo:          kindString,
+	FieldMethod:      kindNumber,
+	FieldValue:       kindNumber,
+	FieldExitCode:    kindNumber,
+	FieldActorFamily: kindString,
+	FieldGasUsed:     kindNumber,
+	FieldHeight:      kindNumber,
+	FieldIsSubCall:   kindBool,
+	FieldImplicit:    kindBool,
+}
+
+// Env provides the field values of the message being evaluated.
+// Number fields may be any integer type, *big.Int or big.Int from go-state-types,
+// string fields are string and bool fields are bool.
+// Get is only called for the fields used by the expression.
+type Env interface {
+	Get(field string) interface{}
+}
+
+// Error reports an invalid expression, Pos is the offset (in characters) of the problem.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func newError(pos int, msg string) *Error {
+	return &Error{Pos: pos, Msg: msg}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter expression: position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Expr is a compiled filter expression, it is safe for concurrent use.
+type Expr struct {
+	src    string
+	root   node
+	fields map[string]struct{}
+}
+
+// Compile parses and type checks the expression.
+func Compile(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, fields: make(map[string]struct{})}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, root: root, fields: p.fields}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Uses reports whether the expression reads the field.
+func (e *Expr) Uses(field string) bool {
+	_, ok := e.fields[field]
+	return ok
+}
+
+// Match evaluates the expression against the env.
+func (e *Expr) Match(env Env) (matched bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			matched, err = false, fmt.Errorf("filter expression: %v", r)
+		}
+	}()
+	return e.root.eval(env).(bool), nil
+}
+
+// toValue converts the field value returned by Env to the value used in evaluation.
+func toValue(field string, k kind, v interface{}) interface{} {
+	switch k {
+	case kindString:
+		if s, ok := v.(string); ok {
+			return s
+		}
+	case kindBool:
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	case kindNumber:
+		switch n := v.(type) {
+		case *big.Int:
+			if n == nil {
+				return new(big.Int)
+			}
+			return n
+		case fbig.Int:
+			if n.Int == nil {
+				return new(big.Int)
+			}
+			return n.Int
+		}
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return big.NewInt(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return new(big.Int).SetUint64(rv.Uint())
+		}
+	}
+	panic(fmt.Sprintf("unexpected value %v(%T) of field %s", v, v, field))
+}
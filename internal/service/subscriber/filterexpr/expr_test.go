@@ -0,0 +1,98 @@
+package filterexpr
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapEnv map[string]interface{}
+
+func (env mapEnv) Get(field string) interface{} {
+	return env[field]
+}
+
+func TestExpr_Match(t *testing.T) {
+	env := mapEnv{
+		FieldFrom:        "t01000",
+		FieldTo:          "t01001",
+		FieldMethod:      abi.MethodNum(2),
+		FieldValue:       big.Mul(big.NewInt(15), big.NewInt(1e17)),
+		FieldExitCode:    exitcode.Ok,
+		FieldActorFamily: "multisig",
+		FieldGasUsed:     int64(12345),
+		FieldHeight:      abi.ChainEpoch(100),
+		FieldIsSubCall:   false,
+		FieldImplicit:    false,
+	}
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"field bool", "implicit", false},
+		{"not", "!is_subcall", true},
+		{"keyword not", "not is_subcall and not implicit", true},
+		{"string eq", `to == "t01001"`, true},
+		{"single quote", `from != 't01000'`, false},
+		{"number", "method == 2 && exit_code == 0", true},
+		{"fil unit", "value >= 1fil && value < 1.6fil", true},
+		{"attofil", "value > 1500000000000000000attofil", false},
+		{"in", `actor_family in ["miner", "multisig"]`, true},
+		{"not in", "!(method in [3, 4])", true},
+		{"precedence", "height < 10 && gas_used > 0 || method == 2", true},
+		{"parentheses", "height < 10 && (gas_used > 0 || method == 2)", false},
+		{"bool eq", "is_subcall == false", true},
+		{"case insensitive", "Method == 2 AND Value > 0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Compile(tt.expr)
+			assert.Nil(t, err)
+			got, err := expr.Match(env)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompile_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		pos  int
+	}{
+		{"empty", "  ", 1},
+		{"unknown field", "method == 2 && foo > 1", 16},
+		{"single eq", "method = 2", 8},
+		{"mismatched", `method == "2"`, 11},
+		{"not bool", "method", 1},
+		{"bool order", "implicit < true", 10},
+		{"unterminated", `to == "t01`, 7},
+		{"unit", "value > 1pfil", 9},
+		{"not integer", "value > 1.5", 9},
+		{"missing paren", "(method == 2", 13},
+		{"list type", `method in [1, "2"]`, 15},
+		{"trailing", "method == 2 3", 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			e, ok := err.(*Error)
+			if assert.True(t, ok, "Compile() error = %v", err) {
+				assert.Equal(t, tt.pos, e.Pos+1, e.Error())
+			}
+		})
+	}
+}
+
+func TestExpr_Uses(t *testing.T) {
+	expr, err := Compile(`actor_family == "miner" || value > 0`)
+	assert.Nil(t, err)
+	assert.True(t, expr.Uses(FieldActorFamily))
+	assert.True(t, expr.Uses(FieldValue))
+	assert.False(t, expr.Uses(FieldFrom))
+}
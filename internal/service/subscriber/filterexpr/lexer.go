@@ -0,0 +1,182 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenTrue
+	tokenFalse
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenIn
+	tokenEq
+	tokenNe
+	tokenLt
+	tokenLe
+	tokenGt
+	tokenGe
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+var keywords = map[string]tokenKind{
+	"true":  tokenTrue,
+	"false": tokenFalse,
+	"and":   tokenAnd,
+	"or":    tokenOr,
+	"not":   tokenNot,
+	"in":    tokenIn,
+}
+
+type token struct {
+	kind tokenKind
+	// text the literal of ident and number, the unquoted value of string
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func lex(src string) ([]token, error) {
+	l := &lexer{src: []rune(src)}
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+	start := l.pos
+	r := l.src[l.pos]
+	switch {
+	case r == '_' || unicode.IsLetter(r):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(l.src[l.pos]) || unicode.IsDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		text := string(l.src[start:l.pos])
+		if kind, ok := keywords[strings.ToLower(text)]; ok {
+			return token{kind: kind, text: text, pos: start}, nil
+		}
+		return token{kind: tokenIdent, text: text, pos: start}, nil
+	case unicode.IsDigit(r):
+		for l.pos < len(l.src) && (unicode.IsDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		// the unit of the number, e.g. 10fil
+		for l.pos < len(l.src) && unicode.IsLetter(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenNumber, text: string(l.src[start:l.pos]), pos: start}, nil
+	case r == '"' || r == '\'':
+		return l.lexString(r)
+	}
+
+	two := ""
+	if l.pos+1 < len(l.src) {
+		two = string(l.src[l.pos : l.pos+2])
+	}
+	switch two {
+	case "==":
+		l.pos += 2
+		return token{kind: tokenEq, text: two, pos: start}, nil
+	case "!=":
+		l.pos += 2
+		return token{kind: tokenNe, text: two, pos: start}, nil
+	case "<=":
+		l.pos += 2
+		return token{kind: tokenLe, text: two, pos: start}, nil
+	case ">=":
+		l.pos += 2
+		return token{kind: tokenGe, text: two, pos: start}, nil
+	case "&&":
+		l.pos += 2
+		return token{kind: tokenAnd, text: two, pos: start}, nil
+	case "||":
+		l.pos += 2
+		return token{kind: tokenOr, text: two, pos: start}, nil
+	}
+
+	l.pos++
+	switch r {
+	case '<':
+		return token{kind: tokenLt, text: "<", pos: start}, nil
+	case '>':
+		return token{kind: tokenGt, text: ">", pos: start}, nil
+	case '!':
+		return token{kind: tokenNot, text: "!", pos: start}, nil
+	case '(':
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+	case ')':
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+	case '[':
+		return token{kind: tokenLBracket, text: "[", pos: start}, nil
+	case ']':
+		return token{kind: tokenRBracket, text: "]", pos: start}, nil
+	case ',':
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+	case '=':
+		return token{}, newError(start, "unexpected \"=\", use \"==\" to compare")
+	case '&':
+		return token{}, newError(start, "unexpected \"&\", use \"&&\"")
+	case '|':
+		return token{}, newError(start, "unexpected \"|\", use \"||\"")
+	}
+	return token{}, newError(start, fmt.Sprintf("unexpected character %q", r))
+}
+
+func (l *lexer) lexString(quote rune) (token, error) {
+	start := l.pos
+	l.pos++
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case r == quote:
+			l.pos++
+			return token{kind: tokenString, text: sb.String(), pos: start}, nil
+		case r == '\\' && l.pos+1 < len(l.src):
+			sb.WriteRune(l.src[l.pos+1])
+			l.pos += 2
+		default:
+			sb.WriteRune(r)
+			l.pos++
+		}
+	}
+	return token{}, newError(start, "unterminated string")
+}
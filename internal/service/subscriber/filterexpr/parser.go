@@ -0,0 +1,342 @@
+package filterexpr
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type kind int8
+
+const (
+	kindBool kind = iota + 1
+	kindNumber
+	kindString
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "bool"
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	}
+	return "unknown"
+}
+
+// units of the number literal, the value field is in attoFIL
+var units = map[string]*big.Int{
+	"":        big.NewInt(1),
+	"attofil": big.NewInt(1),
+	"nanofil": big.NewInt(1e9),
+	"fil":     big.NewInt(1e18),
+}
+
+type node interface {
+	kind() kind
+	eval(env Env) interface{}
+}
+
+type (
+	fieldNode struct {
+		name string
+		k    kind
+	}
+	literalNode struct {
+		k     kind
+		value interface{}
+	}
+	notNode struct {
+		x node
+	}
+	logicNode struct {
+		and         bool
+		left, right node
+	}
+	compareNode struct {
+		op          tokenKind
+		left, right node
+	}
+	inNode struct {
+		x    node
+		list []node
+	}
+)
+
+func (n *fieldNode) kind() kind   { return n.k }
+func (n *literalNode) kind() kind { return n.k }
+func (n *notNode) kind() kind     { return kindBool }
+func (n *logicNode) kind() kind   { return kindBool }
+func (n *compareNode) kind() kind { return kindBool }
+func (n *inNode) kind() kind      { return kindBool }
+
+func (n *fieldNode) eval(env Env) interface{} {
+	return toValue(n.name, n.k, env.Get(n.name))
+}
+
+func (n *literalNode) eval(Env) interface{} {
+	return n.value
+}
+
+func (n *notNode) eval(env Env) interface{} {
+	return !n.x.eval(env).(bool)
+}
+
+func (n *logicNode) eval(env Env) interface{} {
+	left := n.left.eval(env).(bool)
+	if n.and != left {
+		// false && ... or true || ...
+		return left
+	}
+	return n.right.eval(env).(bool)
+}
+
+func (n *compareNode) eval(env Env) interface{} {
+	return compare(n.op, n.left.eval(env), n.right.eval(env))
+}
+
+func (n *inNode) eval(env Env) interface{} {
+	x := n.x.eval(env)
+	for _, item := range n.list {
+		if compare(tokenEq, x, item.eval(env)) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(op tokenKind, left, right interface{}) bool {
+	c := 0
+	switch l := left.(type) {
+	case *big.Int:
+		c = l.Cmp(right.(*big.Int))
+	case string:
+		c = strings.Compare(l, right.(string))
+	case bool:
+		if l != right.(bool) {
+			c = 1
+		}
+	}
+	switch op {
+	case tokenEq:
+		return c == 0
+	case tokenNe:
+		return c != 0
+	case tokenLt:
+		return c < 0
+	case tokenLe:
+		return c <= 0
+	case tokenGt:
+		return c > 0
+	case tokenGe:
+		return c >= 0
+	}
+	return false
+}
+
+// parser is a recursive descent parser of the grammar:
+//
+//	expr    = and { ("||" | "or") and }
+//	and     = unary { ("&&" | "and") unary }
+//	unary   = ("!" | "not") unary | compare
+//	compare = operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand | "in" "[" operand { "," operand } "]" ]
+//	operand = field | number | string | "true" | "false" | "(" expr ")"
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]struct{}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(k tokenKind, want string) (token, error) {
+	tok := p.advance()
+	if tok.kind != k {
+		return tok, newError(tok.pos, fmt.Sprintf("expected %s, found %s", want, tok))
+	}
+	return tok, nil
+}
+
+func (p *parser) parse() (node, error) {
+	if p.peek().kind == tokenEOF {
+		return nil, newError(0, "empty expression")
+	}
+	start := p.peek()
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, newError(tok.pos, fmt.Sprintf("unexpected %s", tok))
+	}
+	if root.kind() != kindBool {
+		return nil, newError(start.pos, fmt.Sprintf("expression must be a bool, found %s", root.kind()))
+	}
+	return root, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogic(tokenOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogic(tokenAnd, p.parseUnary)
+}
+
+func (p *parser) parseLogic(op tokenKind, next func() (node, error)) (node, error) {
+	start := p.peek()
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == op {
+		tok := p.advance()
+		if left.kind() != kindBool {
+			return nil, newError(start.pos, fmt.Sprintf("operand of %s must be a bool, found %s", tok, left.kind()))
+		}
+		start = p.peek()
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if right.kind() != kindBool {
+			return nil, newError(start.pos, fmt.Sprintf("operand of %s must be a bool, found %s", tok, right.kind()))
+		}
+		left = &logicNode{and: op == tokenAnd, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind != tokenNot {
+		return p.parseCompare()
+	}
+	tok := p.advance()
+	start := p.peek()
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if x.kind() != kindBool {
+		return nil, newError(start.pos, fmt.Sprintf("operand of %s must be a bool, found %s", tok, x.kind()))
+	}
+	return &notNode{x: x}, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	switch tok.kind {
+	case tokenEq, tokenNe, tokenLt, tokenLe, tokenGt, tokenGe:
+		p.advance()
+		start := p.peek()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != right.kind() {
+			return nil, newError(start.pos, fmt.Sprintf("mismatched types %s %s %s", left.kind(), tok.text, right.kind()))
+		}
+		if left.kind() == kindBool && tok.kind != tokenEq && tok.kind != tokenNe {
+			return nil, newError(tok.pos, fmt.Sprintf("operator %s is not defined on bool", tok))
+		}
+		return &compareNode{op: tok.kind, left: left, right: right}, nil
+	case tokenIn:
+		p.advance()
+		if _, err := p.expect(tokenLBracket, "\"[\""); err != nil {
+			return nil, err
+		}
+		var list []node
+		for {
+			start := p.peek()
+			item, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if item.kind() != left.kind() {
+				return nil, newError(start.pos, fmt.Sprintf("mismatched types %s in list of %s", item.kind(), left.kind()))
+			}
+			list = append(list, item)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.advance()
+		}
+		if _, err := p.expect(tokenRBracket, "\"]\" or \",\""); err != nil {
+			return nil, err
+		}
+		return &inNode{x: left, list: list}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenIdent:
+		name := strings.ToLower(tok.text)
+		k, ok := fields[name]
+		if !ok {
+			return nil, newError(tok.pos, fmt.Sprintf("unknown field %s", tok))
+		}
+		p.fields[name] = struct{}{}
+		return &fieldNode{name: name, k: k}, nil
+	case tokenNumber:
+		n, err := parseNumber(tok.text)
+		if err != nil {
+			return nil, newError(tok.pos, err.Error())
+		}
+		return &literalNode{k: kindNumber, value: n}, nil
+	case tokenString:
+		return &literalNode{k: kindString, value: tok.text}, nil
+	case tokenTrue, tokenFalse:
+		return &literalNode{k: kindBool, value: tok.kind == tokenTrue}, nil
+	case tokenLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "\")\""); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+	return nil, newError(tok.pos, fmt.Sprintf("unexpected %s, expected a field or a value", tok))
+}
+
+// parseNumber parses a non-negative integer with an optional unit, e.g. 100, 1.5fil
+func parseNumber(text string) (*big.Int, error) {
+	i := strings.IndexFunc(text, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+	num, unit := text, ""
+	if i >= 0 {
+		num, unit = text[:i], strings.ToLower(text[i:])
+	}
+	mul, ok := units[unit]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q of number %q", text[i:], text)
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", text)
+	}
+	r.Mul(r, new(big.Rat).SetInt(mul))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("number %q is not an integer", text)
+	}
+	return r.Num(), nil
+}
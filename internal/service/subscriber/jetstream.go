@@ -0,0 +1,179 @@
+package subscriber
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/model"
+	"github.com/nats-io/nats.go"
+	uuid "github.com/satori/go.uuid"
+
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+)
+
+const (
+	// JetStreamNamePrefix the streams are named PULSAR_<appId>, the subjects are pulsar.<appId>
+	JetStreamNamePrefix    = "PULSAR_"
+	JetStreamSubjectPrefix = "pulsar."
+	// DefaultJetStreamMaxAge the notifications are kept for 3 days by default
+	DefaultJetStreamMaxAge = 72 * time.Hour
+	// jetStreamDuplicates the retries of a delivery in the window are stored once
+	jetStreamDuplicates = 2 * time.Minute
+)
+
+var (
+	_ Notify   = (*jetStreamNotify)(nil)
+	_ redriver = (*jetStreamNotify)(nil)
+)
+
+type (
+	// JetStreamRetention how long and how many notifications the stream of each app keeps,
+	// the zero values mean unlimited except MaxAge, which is DefaultJetStreamMaxAge
+	JetStreamRetention struct {
+		MaxAge   time.Duration
+		MaxMsgs  int64
+		MaxBytes int64
+		Replicas int
+		// Memory keep the notifications in memory instead of files
+		Memory bool
+	}
+
+	// jetStreamNotify publish the notifications to the stream of each app, the apps can consume them by
+	// the durable consumers with the explicit acks, and replay them from a sequence or a time after downtime
+	jetStreamNotify struct {
+		connect   *nats.Conn
+		js        nats.JetStreamContext
+		retention JetStreamRetention
+		// streams the appIds whose streams have been created
+		streams sync.Map
+		opts    NotifyOpts
+		// queues each app has its own queue, so that the retries never hold the caller
+		queues *deliveryQueues
+	}
+)
+
+func NewJetStreamNotify(natsUri string, retention JetStreamRetention, optFns ...NotifyOptFn) (Notify, error) {
+	connect, err := nats.Connect(natsUri)
+	if err != nil {
+		return nil, err
+	}
+	js, err := connect.JetStream()
+	if err != nil {
+		connect.Close()
+		return nil, err
+	}
+	if retention.MaxAge <= 0 {
+		retention.MaxAge = DefaultJetStreamMaxAge
+	}
+	n := &jetStreamNotify{
+		connect:   connect,
+		js:        js,
+		retention: retention,
+		opts:      newNotifyOpts(optFns...),
+	}
+	n.queues = newDeliveryQueues(model2.JetStreamChannel, &n.opts)
+	return n, nil
+}
+
+// JetStreamName the stream of the app
+func JetStreamName(appId string) string {
+	return JetStreamNamePrefix + appId
+}
+
+// JetStreamSubject the subject the notifications of the app are published to
+func JetStreamSubject(appId string) string {
+	return JetStreamSubjectPrefix + appId
+}
+
+func (n *jetStreamNotify) streamConfig(appId string) *nats.StreamConfig {
+	cfg := &nats.StreamConfig{
+		Name:       JetStreamName(appId),
+		Subjects:   []string{JetStreamSubject(appId), JetStreamSubject(appId) + ".>"},
+		Retention:  nats.LimitsPolicy,
+		MaxAge:     n.retention.MaxAge,
+		MaxMsgs:    -1,
+		MaxBytes:   -1,
+		Storage:    nats.FileStorage,
+		Replicas:   n.retention.Replicas,
+		Duplicates: jetStreamDuplicates,
+	}
+	if n.retention.MaxMsgs > 0 {
+		cfg.MaxMsgs = n.retention.MaxMsgs
+	}
+	if n.retention.MaxBytes > 0 {
+		cfg.MaxBytes = n.retention.MaxBytes
+	}
+	if n.retention.Memory {
+		cfg.Storage = nats.MemoryStorage
+	}
+	return cfg
+}
+
+// ensureStream create the stream of the app once, the existing one is updated to the retention
+func (n *jetStreamNotify) ensureStream(appId string) error {
+	if _, ok := n.streams.Load(appId); ok {
+		return nil
+	}
+	cfg := n.streamConfig(appId)
+	_, err := n.js.AddStream(cfg)
+	if errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		_, err = n.js.UpdateStream(cfg)
+	}
+	if err != nil {
+		return err
+	}
+	n.streams.Store(appId, struct{}{})
+	return nil
+}
+
+// Notify queue the notification for the apps, it never waits for the acks
+func (n *jetStreamNotify) Notify(appIds []string, msg model.NotifyMessage) error {
+	if len(appIds) == 0 {
+		return nil
+	}
+	msgByte, err := msg.Get()
+	if err != nil {
+		return err
+	}
+	for _, appId := range appIds {
+		to := appId
+		// the retries of the delivery share the msg id, an equal payload delivered again is another message
+		msgId := uuid.NewV4().String()
+		n.queues.enqueue(deliveryKey{appId: to}, delivery{body: msgByte, send: func() (int, error) {
+			return n.publish(to, msgId, msgByte)
+		}})
+	}
+	return nil
+}
+
+// publish wait for the ack of the stream, the retries are deduplicated by the msg id
+func (n *jetStreamNotify) publish(appId, msgId string, msgByte []byte) (int, error) {
+	return n.opts.retry.Do(func() error {
+		if err := n.ensureStream(appId); err != nil {
+			return err
+		}
+		_, err := n.js.Publish(JetStreamSubject(appId), msgByte, nats.MsgId(msgId))
+		return err
+	})
+}
+
+// Redrive publish the dead letter again with the notifications skipped after it,
+// they're new deliveries, so they're not deduplicated against the failed ones
+func (n *jetStreamNotify) Redrive(letter *model2.DeadLetter) error {
+	if letter.Channel != model2.JetStreamChannel {
+		return errOtherChannel
+	}
+	for _, payload := range append([]string{letter.Payload}, letter.Payloads...) {
+		if _, err := n.publish(letter.AppId, uuid.NewV4().String(), []byte(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close wait for the queued notifications to be published
+func (n *jetStreamNotify) Close() {
+	n.queues.Close()
+	n.connect.Close()
+}
@@ -0,0 +1,98 @@
+package subscriber
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func runJetStreamServer(t *testing.T) *server.Server {
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	assert.Nil(t, err)
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready")
+	}
+	return s
+}
+
+func TestJetStreamNotify(t *testing.T) {
+	s := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	notify, err := NewJetStreamNotify(s.ClientURL(), JetStreamRetention{MaxMsgs: 3})
+	assert.Nil(t, err)
+	defer notify.Close()
+
+	nc, err := nats.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+	js, err := nc.JetStream()
+	assert.Nil(t, err)
+	// the notifications are published in the background
+	waitLastSeq := func(appId string, seq uint64) (info *nats.StreamInfo) {
+		assert.Eventually(t, func() bool {
+			info, err = js.StreamInfo(JetStreamName(appId))
+			return err == nil && info.State.LastSeq == seq
+		}, 5*time.Second, 10*time.Millisecond)
+		return info
+	}
+
+	// the consumer of app1 is offline
+	for height := 1; height <= 5; height++ {
+		assert.Nil(t, notify.Notify([]string{"app1", "app2"}, &HeadPayload{Height: abi.ChainEpoch(height)}))
+	}
+	info := waitLastSeq("app1", 5)
+	assert.Equal(t, DefaultJetStreamMaxAge, info.Config.MaxAge)
+	// only the latest 3 are kept
+	assert.Equal(t, uint64(3), info.State.Msgs)
+
+	// the same payload delivered again is another message, while the retries of a delivery are stored once
+	assert.Nil(t, notify.Notify([]string{"app2"}, &HeadPayload{Height: 5}))
+	waitLastSeq("app2", 6)
+	for i := 0; i < 2; i++ {
+		_, err = notify.(*jetStreamNotify).publish("app2", "retried", []byte(`{"Height":6}`))
+		assert.Nil(t, err)
+	}
+	info = waitLastSeq("app2", 7)
+	assert.Equal(t, uint64(3), info.State.Msgs)
+
+	heightsOf := func(msgs []*nats.Msg) (heights []abi.ChainEpoch) {
+		for _, msg := range msgs {
+			var head HeadPayload
+			assert.Nil(t, json.Unmarshal(msg.Data, &head))
+			heights = append(heights, head.Height)
+			assert.Nil(t, msg.Ack())
+		}
+		return
+	}
+	// the durable consumer comes back, acks what it receives
+	sub, err := js.PullSubscribe(JetStreamSubject("app1"), "app1_durable", nats.AckExplicit())
+	assert.Nil(t, err)
+	msgs, err := sub.Fetch(10, nats.MaxWait(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, []abi.ChainEpoch{3, 4, 5}, heightsOf(msgs))
+	assert.Nil(t, notify.Notify([]string{"app1"}, &HeadPayload{Height: 6}))
+	waitLastSeq("app1", 6)
+	msgs, err = sub.Fetch(10, nats.MaxWait(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, []abi.ChainEpoch{6}, heightsOf(msgs))
+
+	// replay from a sequence
+	replay, err := js.PullSubscribe(JetStreamSubject("app1"), "app1_replay",
+		nats.StartSequence(5), nats.AckExplicit())
+	assert.Nil(t, err)
+	msgs, err = replay.Fetch(10, nats.MaxWait(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, []abi.ChainEpoch{5, 6}, heightsOf(msgs))
+}
@@ -2,22 +2,27 @@ package subscriber
 
 import (
 	"github.com/bitrainforest/filmeta-hic/core/threading"
-	"github.com/bitrainforest/filmeta-hic/model"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
 type PackMsg struct {
-	Msg    model.OneMessage
+	Msg    OneMessagePayload
 	AppIds []string
 }
 
-func NewPackMsg(msg model.OneMessage, appIds []string) PackMsg {
+func NewPackMsg(msg OneMessagePayload, appIds []string) PackMsg {
 	return PackMsg{Msg: msg, AppIds: appIds}
 }
 
-func RangMsg(trace types.ExecutionTrace) <-chan types.Message {
+// TraceMsg is a message of the execution trace with the receipt of its execution
+type TraceMsg struct {
+	types.Message
+	Receipt types.MessageReceipt
+}
+
+func RangMsg(trace types.ExecutionTrace) <-chan TraceMsg {
 	list, count := countMsg(trace)
-	ch := make(chan types.Message, count)
+	ch := make(chan TraceMsg, count)
 	threading.GoSafe(func() {
 		for _, msg := range list {
 			ch <- msg
@@ -27,14 +32,18 @@ func RangMsg(trace types.ExecutionTrace) <-chan types.Message {
 	return ch
 }
 
-func countMsg(trace types.ExecutionTrace) ([]types.Message, int) {
+func countMsg(trace types.ExecutionTrace) ([]TraceMsg, int) {
 	var (
-		list  []types.Message
+		list  []TraceMsg
 		total int
 	)
 	total += 1
 	if trace.Msg != nil {
-		list = append(list, *trace.Msg)
+		traceMsg := TraceMsg{Message: *trace.Msg}
+		if trace.MsgRct != nil {
+			traceMsg.Receipt = *trace.MsgRct
+		}
+		list = append(list, traceMsg)
 	}
 	for _, sub := range trace.Subcalls {
 		subList, subTotal := countMsg(sub)
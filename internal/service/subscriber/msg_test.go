@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -116,3 +117,22 @@ func Test_countMsg(t *testing.T) {
 		})
 	}
 }
+
+func Test_countMsgReceipt(t *testing.T) {
+	t0123, err := address.NewFromString("t0123")
+	assert.Nil(t, err)
+
+	trace := types.ExecutionTrace{
+		Msg:    &types.Message{To: t0123, From: t0123, Method: 1, Nonce: 1},
+		MsgRct: &types.MessageReceipt{ExitCode: exitcode.ErrForbidden, GasUsed: 10},
+		Subcalls: []types.ExecutionTrace{
+			{Msg: &types.Message{To: t0123, From: t0123, Method: 2, Nonce: 1}},
+		},
+	}
+	list, count := countMsg(trace)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, exitcode.ErrForbidden, list[0].Receipt.ExitCode)
+	assert.Equal(t, int64(10), list[0].Receipt.GasUsed)
+	// the receipt is missing, so it is the zero value
+	assert.Equal(t, exitcode.Ok, list[1].Receipt.ExitCode)
+}
@@ -1,15 +1,11 @@
 package subscriber
 
 import (
-	"github.com/bitrainforest/filmeta-hic/core/log"
-
-	"github.com/bitrainforest/filmeta-hic/core/threading"
-
 	"github.com/bitrainforest/filmeta-hic/model"
 
 	"github.com/nats-io/nats.go"
 
-	"sync"
+	model2 "github.com/bitrainforest/pulsar/internal/model"
 )
 
 type Notify interface {
@@ -17,41 +13,64 @@ type Notify interface {
 	Close()
 }
 
+// notify publish the notifications to the nats subjects of the apps, each app has its own queue,
+// so that the retries never hold the caller
 type notify struct {
 	connect *nats.Conn
+	opts    NotifyOpts
+	queues  *deliveryQueues
 }
 
-func NewNotify(natsUri string) (Notify, error) {
+func NewNotify(natsUri string, optFns ...NotifyOptFn) (Notify, error) {
 	connect, err := nats.Connect(natsUri)
 	if err != nil {
 		return nil, err
 	}
-	return &notify{connect: connect}, nil
+	n := &notify{connect: connect, opts: newNotifyOpts(optFns...)}
+	n.queues = newDeliveryQueues(model2.NatsChannel, &n.opts)
+	return n, nil
 }
 
+// Notify queue the notification for the apps, it never waits for the publishes
 func (n *notify) Notify(appIds []string, msg model.NotifyMessage) error {
-	var wg sync.WaitGroup
+	if len(appIds) == 0 {
+		return nil
+	}
+	msgByte, err := msg.Get()
+	if err != nil {
+		return err
+	}
 	for _, appId := range appIds {
-		wg.Add(1)
 		to := appId
-		// todo should  to control the number of goroutine?
-		threading.GoSafe(func() {
-			defer wg.Done()
-			msgByte, err := msg.Get()
-			if err != nil {
-				log.Errorf("[core.processing] marshal msg:%+v err: %s", msg, err)
-				return
-			}
-			// todo add retry
-			if err := n.connect.Publish(to, msgByte); err != nil {
-				log.Errorf("[core.processing] publish appId:%v msg:%+v err: %s", to, msg, err)
-			}
-		})
+		n.queues.enqueue(deliveryKey{appId: to}, delivery{body: msgByte, send: func() (int, error) {
+			return n.publish(to, msgByte)
+		}})
+	}
+	return nil
+}
+
+// publish retry the failed publish
+func (n *notify) publish(appId string, msgByte []byte) (int, error) {
+	return n.opts.retry.Do(func() error {
+		return n.connect.Publish(appId, msgByte)
+	})
+}
+
+// Redrive publish the dead letter again with the notifications skipped after it
+func (n *notify) Redrive(letter *model2.DeadLetter) error {
+	if letter.Channel != model2.NatsChannel {
+		return errOtherChannel
+	}
+	for _, payload := range append([]string{letter.Payload}, letter.Payloads...) {
+		if _, err := n.publish(letter.AppId, []byte(payload)); err != nil {
+			return err
+		}
 	}
-	wg.Wait()
 	return nil
 }
 
+// Close wait for the queued notifications to be published
 func (n *notify) Close() {
+	n.queues.Close()
 	n.connect.Close()
 }
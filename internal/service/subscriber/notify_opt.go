@@ -0,0 +1,114 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+
+	"github.com/bitrainforest/pulsar/internal/dao"
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+)
+
+// errOtherChannel the dead letter is not delivered by the notify
+var errOtherChannel = errors.New("the dead letter is delivered by another channel")
+
+type (
+	// redriver the notifies which can deliver the dead letters again
+	redriver interface {
+		Redrive(letter *model2.DeadLetter) error
+	}
+
+	NotifyOptFn func(opts *NotifyOpts)
+	NotifyOpts  struct {
+		retry RetryPolicy
+		// deadLetterDao stores the notifications which are failed after all the retries
+		deadLetterDao dao.DeadLetterDao
+		// cooldown how long an endpoint or an app is skipped after a notification failed all the retries
+		cooldown time.Duration
+	}
+)
+
+func defaultNotifyOpts() NotifyOpts {
+	return NotifyOpts{
+		retry:         DefaultRetryPolicy,
+		deadLetterDao: dao.NewDeadLetterDao(),
+		cooldown:      DefaultEndpointCooldown,
+	}
+}
+
+func newNotifyOpts(optFns ...NotifyOptFn) NotifyOpts {
+	opts := defaultNotifyOpts()
+	for _, opt := range optFns {
+		opt(&opts)
+	}
+	if opts.retry.Attempts <= 0 {
+		opts.retry.Attempts = 1
+	}
+	return opts
+}
+
+// WithRetryPolicy set how the failed deliveries are retried
+func WithRetryPolicy(retry RetryPolicy) NotifyOptFn {
+	return func(opts *NotifyOpts) {
+		opts.retry = retry
+	}
+}
+
+// WithEndpointCooldown set how long a failed endpoint or app is skipped before it's tried again
+func WithEndpointCooldown(cooldown time.Duration) NotifyOptFn {
+	return func(opts *NotifyOpts) {
+		opts.cooldown = cooldown
+	}
+}
+
+// WithDeadLetterDao set the store of the failed deliveries
+func WithDeadLetterDao(deadLetter dao.DeadLetterDao) NotifyOptFn {
+	return func(opts *NotifyOpts) {
+		if deadLetter != nil {
+			opts.deadLetterDao = deadLetter
+		}
+	}
+}
+
+// deadLetter store the notification which is failed after all the retries, so that the app can re-drive it,
+// nil if it's not stored
+func (opts *NotifyOpts) deadLetter(channel, appId, url string, payload []byte, attempts int,
+	err error) *model2.DeadLetter {
+	letter := model2.NewDefaultDeadLetter()
+	letter.AppId = appId
+	letter.Channel = channel
+	letter.Url = url
+	letter.Payload = string(payload)
+	letter.Err = err.Error()
+	letter.Attempts = attempts
+	if err := opts.deadLetterDao.Create(context.Background(), &letter); err != nil {
+		log.Errorf("[deadLetter] store appId:%v channel:%v payload:%s err: %s", appId, channel, payload, err)
+		return nil
+	}
+	return &letter
+}
+
+// Redrive deliver the dead letter again by the notify of its channel
+func (sub *Subscriber) Redrive(letter *model2.DeadLetter) error {
+	r, ok := sub.notify.(redriver)
+	if !ok {
+		return errors.New("the notify can't redrive the dead letters")
+	}
+	return r.Redrive(letter)
+}
+
+// Redrive the first notify of the channel of the dead letter delivers it
+func (m multiNotify) Redrive(letter *model2.DeadLetter) error {
+	for _, n := range m {
+		r, ok := n.(redriver)
+		if !ok {
+			continue
+		}
+		if err := r.Redrive(letter); !errors.Is(err, errOtherChannel) {
+			return err
+		}
+	}
+	return errOtherChannel
+}
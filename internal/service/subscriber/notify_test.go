@@ -1,6 +1,7 @@
 package subscriber
 
 import (
+	"fmt"
 	"math/rand"
 	"sync"
 	"sync/atomic"
@@ -12,6 +13,8 @@ import (
 	"github.com/nats-io/nats.go"
 
 	"github.com/bitrainforest/filmeta-hic/model"
+
+	model2 "github.com/bitrainforest/pulsar/internal/model"
 )
 
 var _ Notify = (*MockNotify)(nil)
@@ -70,3 +73,47 @@ func Test_Notify(t *testing.T) {
 	time.Sleep(2 * time.Second)
 	assert.Equal(t, int64(nums), atomic.LoadInt64(&recvCount))
 }
+
+func TestNotify_Queued(t *testing.T) {
+	s := runJetStreamServer(t)
+	defer s.Shutdown()
+
+	deadLetter := &MockDeadLetterDao{}
+	n, err := NewNotify(s.ClientURL(),
+		WithRetryPolicy(RetryPolicy{Attempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 200 * time.Millisecond}),
+		WithDeadLetterDao(deadLetter), WithEndpointCooldown(time.Hour))
+	assert.Nil(t, err)
+
+	nc, err := nats.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+	msgs := make(chan *nats.Msg, 10)
+	_, err = nc.ChanSubscribe("app1", msgs)
+	assert.Nil(t, err)
+	assert.Nil(t, nc.Flush())
+	assert.Nil(t, n.Notify([]string{"app1"}, rawMessage(`{"Height":100}`)))
+	select {
+	case msg := <-msgs:
+		assert.JSONEq(t, `{"Height":100}`, string(msg.Data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notification is not published")
+	}
+
+	// the publishes fail from now on, the notify never waits for the retries
+	n.(*notify).connect.Close()
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		assert.Nil(t, n.Notify([]string{"app1"}, rawMessage(fmt.Sprintf(`{"Height":%d}`, 100+i))))
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	n.Close()
+
+	// the app is skipped after the first notification failed all the retries, the skipped ones share its dead letter
+	if assert.Len(t, deadLetter.letters, 1) {
+		letter := deadLetter.letters[0]
+		assert.Equal(t, model2.NatsChannel, letter.Channel)
+		assert.Equal(t, 3, letter.Attempts)
+		assert.JSONEq(t, `{"Height":101}`, letter.Payload)
+		assert.Len(t, letter.Payloads, 2)
+	}
+}
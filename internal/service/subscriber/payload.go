@@ -0,0 +1,210 @@
+package subscriber
+
+import (
+	"encoding/json"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/filmeta-hic/model"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/internal/service/subscriber/statechange"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+
+	// the actor packages register the methods of all versions to decode the params and the return
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/account"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/cron"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/init"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/market"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/miner"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/multisig"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/paych"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/power"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/reward"
+	_ "github.com/bitrainforest/pulsar/chain/actors/builtin/verifreg"
+)
+
+type (
+	// DecodedCall the params and the return value decoded against the actor code of the receiver
+	// and the method number, they are the raw bytes if the method is unknown.
+	DecodedCall struct {
+		ActorCode cid.Cid     `json:"actor_code"`
+		Params    interface{} `json:"params"`
+		Return    interface{} `json:"return"`
+		// Raw whether Params and Return are the raw bytes
+		Raw bool `json:"raw"`
+	}
+
+	// ActorMethod the human-readable names of the receiver and the method
+	ActorMethod struct {
+		ActorName   string `json:"actor_name"`
+		ActorFamily string `json:"actor_family"`
+		MethodName  string `json:"method_name"`
+	}
+
+	// MessagePayload is published to the apps who subscribe all addresses
+	MessagePayload struct {
+		*model.Message
+		DecodedCall
+		ActorMethod
+	}
+
+	// RevertedPayload is published for a message or a state change event delivered before,
+	// whose tipset is reverted by a reorg, the message may be delivered again if it's included by another tipset
+	RevertedPayload struct {
+		// Reverted always true, so that the apps can tell it from the other payloads
+		Reverted  bool            `json:"Reverted"`
+		TipSet    types.TipSetKey `json:"TipSet"`
+		Height    abi.ChainEpoch  `json:"Height"`
+		MCid      cid.Cid         `json:"MCid"`
+		Cid       cid.Cid         `json:"Cid"`
+		IsSubCall bool            `json:"IsSubCall"`
+		// Kind and Address the reverted state change event, the cids are null then
+		Kind    string `json:"Kind,omitempty"`
+		Address string `json:"Address,omitempty"`
+	}
+
+	// PendingPayload is published when a message of the subscribed address enters or leaves the mpool,
+	// the applied notification of the message follows, correlated by the Cid
+	PendingPayload struct {
+		// Status PendingStatus or RemovedStatus
+		Status  string        `json:"Status"`
+		Cid     cid.Cid       `json:"Cid"`
+		Message types.Message `json:"Message"`
+	}
+
+	// HeadPayload is published for each new head to the apps who subscribe the heads
+	HeadPayload struct {
+		// Head always true, so that the apps can tell it from the other payloads
+		Head            bool              `json:"Head"`
+		Height          abi.ChainEpoch    `json:"Height"`
+		TipSet          types.TipSetKey   `json:"TipSet"`
+		Timestamp       uint64            `json:"Timestamp"`
+		ParentBaseFee   abi.TokenAmount   `json:"ParentBaseFee"`
+		ParentStateRoot cid.Cid           `json:"ParentStateRoot"`
+		BlockCount      int               `json:"BlockCount"`
+		Miners          []address.Address `json:"Miners"`
+		// MessageCount the unique messages of the blocks,
+		// BlockMessageCount the messages of each block are counted, a message may be included by many blocks
+		MessageCount      int `json:"MessageCount"`
+		BlockMessageCount int `json:"BlockMessageCount"`
+	}
+
+	// OneMessagePayload is published for a message of the execution trace
+	OneMessagePayload struct {
+		model.OneMessage
+		// MCid the top-level message on chain, the same as the Cid of its PendingPayload
+		MCid    cid.Cid              `json:"MCid"`
+		Receipt types.MessageReceipt `json:"Receipt"`
+		// Replayed the message is executed before the app subscribes it, it's delivered by a replay
+		Replayed bool `json:"Replayed,omitempty"`
+		DecodedCall
+		ActorMethod
+	}
+)
+
+// the statuses of the PendingPayload
+const (
+	PendingStatus = "pending" // the message enters the mpool
+	RemovedStatus = "removed" // the message leaves the mpool, it's included by a block, replaced or expired
+)
+
+// NewActorMethod the names are "<unknown>" if the code is undefined or not a builtin actor
+func NewActorMethod(code cid.Cid, method abi.MethodNum) ActorMethod {
+	name := builtin.ActorNameByCode(code)
+	return ActorMethod{
+		ActorName:   name,
+		ActorFamily: builtin.ActorFamily(name),
+		MethodName:  builtin.MethodName(code, method),
+	}
+}
+
+// NewDecodedCall decode the params and the return value of the message,
+// the code is undefined if the code of the receiver is unknown
+func NewDecodedCall(code cid.Cid, msg TraceMsg) DecodedCall {
+	call := DecodedCall{ActorCode: code, Params: msg.Params, Return: msg.Receipt.Return, Raw: true}
+	if !code.Defined() {
+		return call
+	}
+	if _, ok := builtin.GetMethodMeta(code, msg.Method); !ok {
+		return call
+	}
+	params, err := builtin.DecodeParams(code, msg.Method, msg.Params)
+	if err != nil {
+		log.Warnf("[NewDecodedCall] decode params of %v method %d err:%v", msg.Cid(), msg.Method, err)
+		return call
+	}
+	ret, err := builtin.DecodeReturn(code, msg.Method, msg.Receipt.Return)
+	if err != nil {
+		log.Warnf("[NewDecodedCall] decode return of %v method %d err:%v", msg.Cid(), msg.Method, err)
+		return call
+	}
+	call.Params, call.Return, call.Raw = params, ret, false
+	return call
+}
+
+// DecodeByEnv decode the message of the env against the code of the receiver,
+// and name the receiver and the method
+func DecodeByEnv(env *MsgEnv) (DecodedCall, ActorMethod) {
+	code, err := env.ActorCode()
+	if err != nil {
+		log.Warnf("[DecodeByEnv] get actor code of %v err:%v", env.Msg.To.String(), err)
+		code = cid.Undef
+	}
+	return NewDecodedCall(code, env.Msg), NewActorMethod(code, env.Msg.Method)
+}
+
+func (payload *MessagePayload) Get() ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (payload *OneMessagePayload) Get() ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+// NewHeadPayload the message counts are from the message cids of the blocks, nil if not loaded
+func NewHeadPayload(ts *types.TipSet, blockMsgCids [][]cid.Cid) *HeadPayload {
+	head := &HeadPayload{
+		Head:            true,
+		Height:          ts.Height(),
+		TipSet:          ts.Key(),
+		Timestamp:       ts.MinTimestamp(),
+		ParentBaseFee:   ts.Blocks()[0].ParentBaseFee,
+		ParentStateRoot: ts.ParentState(),
+		BlockCount:      len(ts.Blocks()),
+	}
+	for _, blk := range ts.Blocks() {
+		head.Miners = append(head.Miners, blk.Miner)
+	}
+	unique := make(map[cid.Cid]struct{})
+	for _, msgCids := range blockMsgCids {
+		head.BlockMessageCount += len(msgCids)
+		for _, c := range msgCids {
+			unique[c] = struct{}{}
+		}
+	}
+	head.MessageCount = len(unique)
+	return head
+}
+
+func (payload *PendingPayload) Get() ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (payload *HeadPayload) Get() ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (payload *RevertedPayload) Get() ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+// EventPayload the payload of a state change event
+type EventPayload struct {
+	*statechange.Event
+}
+
+func (payload *EventPayload) Get() ([]byte, error) {
+	return json.Marshal(payload)
+}
@@ -0,0 +1,97 @@
+package subscriber
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	miner7 "github.com/filecoin-project/specs-actors/v7/actors/builtin/miner"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDecodedCall(t *testing.T) {
+	params := miner7.ChangePeerIDParams{NewID: abi.PeerID("peer")}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, params.MarshalCBOR(buf))
+	msg := TraceMsg{Message: types.Message{
+		To:     GenerateAddress("t01000"),
+		From:   GenerateAddress("t01001"),
+		Method: builtin7.MethodsMiner.ChangePeerID,
+		Params: buf.Bytes(),
+	}}
+
+	tests := []struct {
+		name   string
+		code   cid.Cid
+		method abi.MethodNum
+		raw    bool
+	}{
+		{name: "decoded", code: builtin7.StorageMinerActorCodeID, method: builtin7.MethodsMiner.ChangePeerID},
+		{name: "unknownCode", code: cid.Undef, method: builtin7.MethodsMiner.ChangePeerID, raw: true},
+		{name: "unknownMethod", code: builtin7.StorageMinerActorCodeID, method: 1000, raw: true},
+		{name: "mismatchedParams", code: builtin7.StorageMinerActorCodeID,
+			method: builtin7.MethodsMiner.ChangeWorkerAddress, raw: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := msg
+			msg.Method = tt.method
+			call := NewDecodedCall(tt.code, msg)
+			assert.Equal(t, tt.raw, call.Raw)
+			if tt.raw {
+				assert.Equal(t, msg.Params, call.Params)
+				return
+			}
+			assert.Equal(t, &params, call.Params)
+			assert.Nil(t, call.Return)
+		})
+	}
+
+	// the fields are snake_case as the ones of the ActorMethod
+	b, err := json.Marshal(NewDecodedCall(cid.Undef, msg))
+	assert.Nil(t, err)
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b, &fields))
+	for _, field := range []string{"actor_code", "params", "return", "raw"} {
+		assert.Contains(t, fields, field)
+	}
+}
+
+func TestNewActorMethod(t *testing.T) {
+	got := NewActorMethod(builtin7.StorageMinerActorCodeID, builtin7.MethodsMiner.ChangePeerID)
+	assert.Equal(t, ActorMethod{
+		ActorName:   "fil/7/storageminer",
+		ActorFamily: "storageminer",
+		MethodName:  "ChangePeerID",
+	}, got)
+
+	got = NewActorMethod(cid.Undef, builtin7.MethodSend)
+	assert.Equal(t, ActorMethod{ActorName: "<unknown>", ActorFamily: "<unknown>", MethodName: "Send"}, got)
+}
+
+func TestNewHeadPayload(t *testing.T) {
+	blk1 := mock.MkBlock(nil, 1, 1)
+	blk2 := mock.MkBlock(nil, 1, 2)
+	blk2.Miner = GenerateAddress("t01001")
+	ts := mock.TipSet(blk1, blk2)
+	shared, only := RandCId("shared"), RandCId("only")
+
+	head := NewHeadPayload(ts, [][]cid.Cid{{shared}, {shared, only}})
+	assert.True(t, head.Head)
+	assert.Equal(t, ts.Height(), head.Height)
+	assert.Equal(t, ts.Key(), head.TipSet)
+	assert.Equal(t, ts.ParentState(), head.ParentStateRoot)
+	assert.Equal(t, 2, head.BlockCount)
+	assert.ElementsMatch(t, []address.Address{blk1.Miner, blk2.Miner}, head.Miners)
+	assert.Equal(t, 2, head.MessageCount)
+	assert.Equal(t, 3, head.BlockMessageCount)
+
+	head = NewHeadPayload(ts, nil)
+	assert.Equal(t, 0, head.MessageCount)
+}
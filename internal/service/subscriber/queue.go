@@ -0,0 +1,167 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/filmeta-hic/core/threading"
+
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+)
+
+const (
+	// DeliveryQueueSize how many notifications a target holds while it's delivering, the later ones are dead letters
+	DeliveryQueueSize = 1024
+	// DefaultEndpointCooldown a target which failed all the retries is skipped for a while,
+	// its notifications go to the dead letter at once
+	DefaultEndpointCooldown = time.Minute
+)
+
+// errEndpointDown the notification is skipped since the target failed recently or it's too slow
+var errEndpointDown = errors.New("the endpoint is down")
+
+type (
+	// deliveryKey the target of the notifications, the url is empty if the channel has no endpoints
+	deliveryKey struct {
+		appId, url string
+	}
+
+	// delivery a queued notification, send delivers the body with the retries
+	delivery struct {
+		body []byte
+		send func() (attempts int, err error)
+	}
+
+	// deliveryQueue the notifications of a target are delivered in order by its own worker,
+	// so that a slow or dead target never delays the others
+	deliveryQueue struct {
+		deliveryKey
+		deliveries chan delivery
+		// lock guards the fields below, the deliveries which don't fit the queue are dead letters as well
+		lock sync.Mutex
+		// downUntil the target is skipped until then
+		downUntil time.Time
+		// letter the dead letter of the current outage, the skipped notifications are appended to it
+		letter *model2.DeadLetter
+	}
+
+	// deliveryQueues the queues of the targets of a channel, the notify only queues the notifications
+	// and never waits for the retries
+	deliveryQueues struct {
+		channel string
+		opts    *NotifyOpts
+		now     func() time.Time
+		// lock guards the queues, they're closed when the targets are removed
+		lock   sync.Mutex
+		queues map[deliveryKey]*deliveryQueue
+		closed bool
+		wg     sync.WaitGroup
+	}
+)
+
+func newDeliveryQueues(channel string, opts *NotifyOpts) *deliveryQueues {
+	return &deliveryQueues{
+		channel: channel,
+		opts:    opts,
+		now:     time.Now,
+		queues:  make(map[deliveryKey]*deliveryQueue),
+	}
+}
+
+func (d *deliveryQueues) enqueue(key deliveryKey, item delivery) {
+	d.lock.Lock()
+	if d.closed {
+		d.lock.Unlock()
+		return
+	}
+	queue, ok := d.queues[key]
+	if !ok {
+		queue = &deliveryQueue{deliveryKey: key, deliveries: make(chan delivery, DeliveryQueueSize)}
+		d.queues[key] = queue
+		d.wg.Add(1)
+		threading.GoSafe(func() {
+			defer d.wg.Done()
+			d.work(queue)
+		})
+	}
+	var queued bool
+	select {
+	case queue.deliveries <- item:
+		queued = true
+	default:
+	}
+	d.lock.Unlock()
+	if !queued {
+		log.Errorf("[deliveryQueues] the %v queue of appId:%v url:%v is full", d.channel, key.appId, key.url)
+		d.deadLetter(queue, item.body, 0, errEndpointDown)
+	}
+}
+
+// work deliver the notifications of the target until the queue is closed
+func (d *deliveryQueues) work(queue *deliveryQueue) {
+	for item := range queue.deliveries {
+		queue.lock.Lock()
+		down := d.now().Before(queue.downUntil)
+		queue.lock.Unlock()
+		if down {
+			d.deadLetter(queue, item.body, 0, errEndpointDown)
+			continue
+		}
+		attempts, err := item.send()
+		queue.lock.Lock()
+		if err != nil {
+			queue.downUntil = d.now().Add(d.opts.cooldown)
+		} else {
+			// the next outage has its own dead letter
+			queue.letter = nil
+		}
+		queue.lock.Unlock()
+		if err != nil {
+			log.Errorf("[deliveryQueues] deliver %v appId:%v url:%v attempts:%d err: %s",
+				d.channel, queue.appId, queue.url, attempts, err)
+			d.deadLetter(queue, item.body, attempts, err)
+		}
+	}
+}
+
+// deadLetter the notifications failed in an outage of the target share a dead letter
+func (d *deliveryQueues) deadLetter(queue *deliveryQueue, body []byte, attempts int, err error) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if letter := queue.letter; letter != nil && len(letter.Payloads) < model2.MaxDeadLetterPayloads {
+		appendErr := d.opts.deadLetterDao.AppendPayload(context.Background(), letter.AppId, letter.Id.Hex(), string(body))
+		if appendErr == nil {
+			letter.Payloads = append(letter.Payloads, string(body))
+			return
+		}
+		log.Errorf("[deliveryQueues] append to dead letter %v err: %s", letter.Id.Hex(), appendErr)
+	}
+	queue.letter = d.opts.deadLetter(d.channel, queue.appId, queue.url, body, attempts, err)
+}
+
+// remove close the queues of the app whose urls are not kept, the queued notifications are still delivered
+func (d *deliveryQueues) remove(appId string, kept map[string]bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for key, queue := range d.queues {
+		if key.appId == appId && !kept[key.url] {
+			close(queue.deliveries)
+			delete(d.queues, key)
+		}
+	}
+}
+
+// Close wait for the queued notifications to be delivered
+func (d *deliveryQueues) Close() {
+	d.lock.Lock()
+	d.closed = true
+	for key, queue := range d.queues {
+		close(queue.deliveries)
+		delete(d.queues, key)
+	}
+	d.lock.Unlock()
+	d.wg.Wait()
+}
@@ -0,0 +1,275 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/filmeta-hic/core/store/redisx"
+	"github.com/bitrainforest/filmeta-hic/core/threading"
+	"github.com/bitrainforest/filmeta-hic/model"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/bitrainforest/pulsar/internal/cache"
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+	"github.com/bitrainforest/pulsar/internal/utils/locker"
+)
+
+const (
+	// MaxReplayEpochs the height range of a replay is one day at most
+	MaxReplayEpochs = 2880
+	// ReplayLockExpire seconds, the lock of the app is refreshed by the progress of its replay,
+	// it's released at last or expired if the instance is gone
+	ReplayLockExpire uint32 = 600
+)
+
+var (
+	ErrChainNotReady = errors.New("the chain is not ready")
+	// ErrNotSubscribed a replayed address must be subscribed by the app
+	ErrNotSubscribed = errors.New("the address is not subscribed")
+	// ErrReplayRunning an app runs one replay at a time, whichever instance runs it
+	ErrReplayRunning = errors.New("a replay of the app is running")
+)
+
+type (
+	// ReplayParams replay the messages of the addresses executed in [From, To] to the app
+	ReplayParams struct {
+		AppId     string
+		Addresses []address.Address
+		From, To  abi.ChainEpoch
+	}
+
+	// executedTipSet the messages of the tipset are executed by the child, the receipts are in the child
+	executedTipSet struct {
+		ts, child *types.TipSet
+	}
+)
+
+// Replay start a job in the background which walks the tipsets of the chain store and delivers the historical
+// messages of the addresses to the app, they are matched by the filters of the subs of the app, and tagged as
+// replayed. The receipts are read instead of executing the tipsets again, so the sub calls and the implicit
+// messages are not replayed. The progress of the job is looked up by ReplayJob
+func (sub *Subscriber) Replay(ctx context.Context, params ReplayParams) (job cache.ReplayJob, err error) {
+	if sub.opts.chainStore == nil {
+		return job, ErrChainNotReady
+	}
+	if params.To < params.From || params.To-params.From >= MaxReplayEpochs {
+		return job, fmt.Errorf("the height range must be in %d epochs", MaxReplayEpochs)
+	}
+	filters, err := sub.replayFilters(ctx, params)
+	if err != nil {
+		return job, err
+	}
+	job = cache.ReplayJob{
+		Id:           uuid.NewV4().String(),
+		AppId:        params.AppId,
+		From:         params.From,
+		To:           params.To,
+		Status:       cache.ReplayRunning,
+		Height:       params.From - 1,
+		TopLevelOnly: true,
+		UpdatedAt:    time.Now(),
+	}
+	lock := locker.NewRedisLock(ctx, replayLockKey(params.AppId), ReplayLockExpire)
+	ok, err := lock.Acquire(ctx)
+	if err != nil {
+		return job, err
+	}
+	if !ok {
+		return job, ErrReplayRunning
+	}
+	if err = sub.opts.replayJobs.Save(ctx, job); err != nil {
+		lock.Release(ctx)
+		return job, err
+	}
+	sub.wg.Add(1)
+	threading.GoSafe(func() {
+		defer sub.wg.Done()
+		ctx := context.Background()
+		defer lock.Release(ctx)
+		sub.runReplay(ctx, job, filters, lock)
+	})
+	return job, nil
+}
+
+func replayLockKey(appId string) string {
+	return "replay_lock:" + appId
+}
+
+// ReplayJob the replay job of the app, ok is false if it doesn't exist
+func (sub *Subscriber) ReplayJob(ctx context.Context, appId, id string) (job cache.ReplayJob, ok bool, err error) {
+	job, ok, err = sub.opts.replayJobs.Get(ctx, id)
+	if err != nil || !ok || job.AppId != appId {
+		return cache.ReplayJob{}, false, err
+	}
+	return job, true, nil
+}
+
+// runReplay the progress is saved after each tipset, so that the clients can follow it,
+// the lock of the app is refreshed with it
+func (sub *Subscriber) runReplay(ctx context.Context, job cache.ReplayJob, filters map[string]*model2.SubFilter,
+	lock *redisx.RedisLock) {
+	save := func() {
+		job.UpdatedAt = time.Now()
+		if err := sub.opts.replayJobs.Save(ctx, job); err != nil {
+			log.Errorf("[Replay] save job %s err:%v", job.Id, err)
+		}
+		if ok, err := lock.Acquire(ctx); err != nil || !ok {
+			log.Errorf("[Replay] refresh the lock of job %s ok:%v err:%v", job.Id, ok, err)
+		}
+	}
+	if err := sub.replayTipSets(ctx, &job, filters, save); err != nil {
+		log.Errorf("[Replay] job %s of %s failed: %v", job.Id, job.AppId, err)
+		job.Status, job.Error = cache.ReplayFailed, err.Error()
+	} else {
+		job.Status = cache.ReplayDone
+	}
+	save()
+}
+
+func (sub *Subscriber) replayTipSets(ctx context.Context, job *cache.ReplayJob, filters map[string]*model2.SubFilter,
+	progress func()) error {
+	cs := sub.opts.chainStore
+	tipsets, err := sub.executedTipSets(ctx, job.From, job.To)
+	if err != nil {
+		return err
+	}
+	for _, executed := range tipsets {
+		msgs, err := cs.MessagesForTipset(ctx, executed.ts)
+		if err != nil {
+			return fmt.Errorf("load messages of %v: %w", executed.ts.Key(), err)
+		}
+		for i, chainMsg := range msgs {
+			receipt, err := cs.GetParentReceipt(ctx, executed.child.Blocks()[0], i)
+			if err != nil {
+				return fmt.Errorf("load receipt of %v: %w", chainMsg.Cid(), err)
+			}
+			msg := &model.Message{
+				TipSet: executed.ts,
+				MCid:   chainMsg.Cid(),
+				Msg:    chainMsg.VMMessage(),
+				Ret: &vm.ApplyRet{
+					MessageReceipt: *receipt,
+					ExecutionTrace: types.ExecutionTrace{Msg: chainMsg.VMMessage(), MsgRct: receipt},
+				},
+			}
+			if sub.replayMessage(ctx, job.AppId, filters, msg) {
+				job.Notified++
+			}
+		}
+		job.TipSets++
+		job.Messages += len(msgs)
+		job.Height = executed.ts.Height()
+		progress()
+	}
+	job.Height = job.To
+	return nil
+}
+
+// replayFilters the filters of the subs of the app, keyed by the address,
+// every address must be subscribed by the app
+func (sub *Subscriber) replayFilters(ctx context.Context, params ReplayParams) (map[string]*model2.SubFilter, error) {
+	filters := make(map[string]*model2.SubFilter, len(params.Addresses))
+	for _, addr := range params.Addresses {
+		appSub, err := sub.opts.appSubDao.GetByAppId(ctx, params.AppId, addr.String())
+		if err != nil {
+			return nil, err
+		}
+		if appSub.IsEmpty() {
+			return nil, fmt.Errorf("%w: %s", ErrNotSubscribed, addr.String())
+		}
+		filter := appSub.SubFilter
+		filters[addr.String()] = &filter
+	}
+	return filters, nil
+}
+
+// executedTipSets the tipsets in [from, to] whose children exist, from the lowest to the highest
+func (sub *Subscriber) executedTipSets(ctx context.Context, from, to abi.ChainEpoch) ([]executedTipSet, error) {
+	cs := sub.opts.chainStore
+	head := cs.GetHeaviestTipSet()
+	if from >= head.Height() {
+		return nil, nil
+	}
+	child := head
+	if to+1 < head.Height() {
+		var err error
+		// the lowest tipset above to, whose parent is the last tipset to replay
+		if child, err = cs.GetTipsetByHeight(ctx, to+1, head, false); err != nil {
+			return nil, err
+		}
+	}
+	var tipsets []executedTipSet
+	for child.Height() > from {
+		ts, err := cs.LoadTipSet(ctx, child.Parents())
+		if err != nil {
+			return nil, err
+		}
+		if ts.Height() < from {
+			break
+		}
+		if ts.Height() <= to {
+			tipsets = append(tipsets, executedTipSet{ts: ts, child: child})
+		}
+		child = ts
+	}
+	for i, j := 0, len(tipsets)-1; i < j; i, j = i+1, j-1 {
+		tipsets[i], tipsets[j] = tipsets[j], tipsets[i]
+	}
+	return tipsets, nil
+}
+
+// replayMessage deliver the message to the app if the from or the to address matches,
+// the direction of the filter decides which side the address must be on
+func (sub *Subscriber) replayMessage(ctx context.Context, appId string, filters map[string]*model2.SubFilter,
+	msg *model.Message) bool {
+	traceMsg := TraceMsg{Message: *msg.Msg, Receipt: msg.Ret.MessageReceipt}
+	env := NewMsgEnv(ctx, sub, traceMsg, msg.TipSet, false, false)
+	from, to := env.Addresses()
+	matched := false
+	if filter, ok := filters[from.String()]; ok && model2.Direction(filter.Direction) != model2.InboundDirection {
+		matched = filter.HasKind(model2.MessageKind) && sub.matchSubFilter(filter, env)
+	}
+	if filter, ok := filters[to.String()]; !matched && ok && model2.Direction(filter.Direction) != model2.OutboundDirection {
+		matched = filter.HasKind(model2.MessageKind) && sub.matchSubFilter(filter, env)
+	}
+	if !matched {
+		return false
+	}
+	payload := OneMessagePayload{
+		OneMessage: model.OneMessage{Msg: traceMsg.Message, TipSet: msg.TipSet},
+		MCid:       msg.MCid,
+		Receipt:    traceMsg.Receipt,
+		Replayed:   true,
+	}
+	payload.DecodedCall, payload.ActorMethod = DecodeByEnv(env)
+	if err := sub.replayDeliver(ctx, appId, msg, &payload); err != nil {
+		log.Errorf("[Replay] Notify %s failed: %v", msg.MCid.String(), err)
+		return false
+	}
+	return true
+}
+
+// replayDeliver the app who wants the tipsets confirmed gets the message at once only if the tipset is deep
+// enough already, or it's held like a live one until the head advances
+func (sub *Subscriber) replayDeliver(ctx context.Context, appId string, msg *model.Message,
+	payload model.NotifyMessage) error {
+	record := messageDelivery(msg, msg.MCid, false)
+	value, ok := sub.appConfirmations.Load(appId)
+	head := sub.opts.chainStore.GetHeaviestTipSet().Height()
+	if !ok || head-msg.TipSet.Height() < value.(abi.ChainEpoch) {
+		_, err := sub.deliver(ctx, msg.TipSet, []string{appId}, payload, record)
+		return err
+	}
+	if err := sub.notify.Notify([]string{appId}, payload); err != nil {
+		return err
+	}
+	sub.journal(ctx, msg.TipSet.Height(), msg.TipSet.Key(), record, []string{appId})
+	return nil
+}
@@ -0,0 +1,47 @@
+package subscriber
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultRetryPolicy a failed delivery is tried 5 times in about 3 seconds
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  5,
+	BaseDelay: 200 * time.Millisecond,
+	MaxDelay:  5 * time.Second,
+}
+
+// RetryPolicy retry the delivery with the exponential backoff and the jitter
+type RetryPolicy struct {
+	// Attempts how many times the delivery is tried, including the first one
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Backoff the delay before the retry after the failed attempt, which starts from 1,
+// it's a random duration in [d/2, d), d doubles on each attempt until MaxDelay
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// Do call fn until it succeeds or the attempts run out, return how many times fn is called and the last error
+func (p RetryPolicy) Do(fn func() error) (attempts int, err error) {
+	for attempts = 1; ; attempts++ {
+		if err = fn(); err == nil || attempts >= p.Attempts {
+			return
+		}
+		time.Sleep(p.Backoff(attempts))
+	}
+}
@@ -0,0 +1,54 @@
+package subscriber
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{Attempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		// the backoff is in [want/2, want)
+		want time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			backoff := p.Backoff(tt.attempt)
+			assert.GreaterOrEqual(t, backoff, tt.want/2)
+			assert.Less(t, backoff, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	calls := 0
+	attempts, err := p.Do(func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+
+	calls = 0
+	attempts, err = p.Do(func() error {
+		calls++
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, calls)
+}
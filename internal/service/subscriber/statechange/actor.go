@@ -0,0 +1,58 @@
+package statechange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	builtininit "github.com/bitrainforest/pulsar/chain/actors/builtin/init"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// ActorCreatedEvent the robust address gets an ID address, that is a new actor is created
+type ActorCreatedEvent struct {
+	Address   address.Address `json:"address"`
+	ID        address.Address `json:"id"`
+	ActorCode cid.Cid         `json:"actor_code"`
+	ActorName string          `json:"actor_name"`
+}
+
+func init() {
+	Register(model.ActorCreatedKind, ExtractorFunc(ExtractActorCreatedEvents))
+}
+
+// ExtractActorCreatedEvents extract the new actors of the subscribed robust addresses,
+// all new actors are sent to the subscribers of the init actor
+func ExtractActorCreatedEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	pairs, err := change.NewAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := addressSet(addrs)
+	_, all := subscribed[builtininit.Address]
+
+	var events []*Event
+	for _, pair := range pairs {
+		if _, ok := subscribed[pair.PK]; !ok && !all {
+			continue
+		}
+		created := &ActorCreatedEvent{Address: pair.PK, ID: pair.ID}
+		_, post, err := change.Actors(pair.ID)
+		if err != nil {
+			return nil, err
+		}
+		if post == nil {
+			return nil, fmt.Errorf("new actor %v not found", pair.ID.String())
+		}
+		created.ActorCode = post.Code
+		created.ActorName = builtin.ActorNameByCode(post.Code)
+
+		event := NewEvent(model.ActorCreatedKind, change, pair.ID, created)
+		event.Routes = []address.Address{pair.PK, builtininit.Address}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,72 @@
+package statechange
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// BalanceEvent the balance of the actor is changed by the change, whatever the cause is,
+// e.g. the messages, the burnt fees, the block rewards or the cron
+type BalanceEvent struct {
+	Address address.Address `json:"address"`
+	From    abi.TokenAmount `json:"from"`
+	To      abi.TokenAmount `json:"to"`
+	// Delta is negative if the balance drops
+	Delta abi.TokenAmount `json:"delta"`
+}
+
+func init() {
+	Register(model.BalanceKind, ExtractorFunc(ExtractBalanceEvents))
+}
+
+// ExtractBalanceEvents extract the balance changes of the subscribed actors, the ID addresses are looked up
+// in the changed actors, the robust addresses are resolved by the state trees
+func ExtractBalanceEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	changed, err := change.ChangedActors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []*Event
+	for _, addr := range addrs {
+		var pre, post *types.Actor
+		if addr.Protocol() == address.ID {
+			actorChange, ok := changed[addr]
+			if !ok {
+				continue
+			}
+			pre, post = actorChange.Pre, actorChange.Post
+		} else if pre, post, err = change.Actors(addr); err != nil {
+			return nil, err
+		}
+		if balance := NewBalanceEvent(addr, pre, post); balance != nil {
+			events = append(events, NewEvent(model.BalanceKind, change, addr, balance))
+		}
+	}
+	return events, nil
+}
+
+// NewBalanceEvent the balance of a missing actor is zero, nil if the balance is not changed
+func NewBalanceEvent(addr address.Address, pre, post *types.Actor) *BalanceEvent {
+	from, to := big.Zero(), big.Zero()
+	if pre != nil {
+		from = pre.Balance
+	}
+	if post != nil {
+		to = post.Balance
+	}
+	if from.Equals(to) {
+		return nil
+	}
+	return &BalanceEvent{
+		Address: addr,
+		From:    from,
+		To:      to,
+		Delta:   big.Sub(to, from),
+	}
+}
@@ -0,0 +1,43 @@
+package statechange
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBalanceEvent(t *testing.T) {
+	actor := func(balance int64) *types.Actor {
+		return &types.Actor{Balance: big.NewInt(balance)}
+	}
+	tests := []struct {
+		name      string
+		pre, post *types.Actor
+		// the wanted from, to and delta, nil means no event
+		want []int64
+	}{
+		{"unchanged", actor(100), actor(100), nil},
+		{"increased", actor(100), actor(150), []int64{100, 150, 50}},
+		{"decreased", actor(100), actor(30), []int64{100, 30, -70}},
+		{"created", nil, actor(10), []int64{0, 10, 10}},
+		{"created empty", nil, actor(0), nil},
+		{"deleted", actor(10), nil, []int64{10, 0, -10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := NewBalanceEvent(testMiner, tt.pre, tt.post)
+			if tt.want == nil {
+				assert.Nil(t, event)
+				return
+			}
+			if assert.NotNil(t, event) {
+				assert.Equal(t, testMiner, event.Address)
+				assert.Equal(t, big.NewInt(tt.want[0]), event.From)
+				assert.Equal(t, big.NewInt(tt.want[1]), event.To)
+				assert.Equal(t, big.NewInt(tt.want[2]), event.Delta)
+			}
+		})
+	}
+}
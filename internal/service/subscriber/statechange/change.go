@@ -0,0 +1,211 @@
+package statechange
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-hamt-ipld/v3"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	"github.com/ipfs/go-cid"
+
+	"github.com/bitrainforest/pulsar/chain/actors/adt"
+	builtininit "github.com/bitrainforest/pulsar/chain/actors/builtin/init"
+)
+
+// Change the state change made by executing the messages of Parent,
+// the state goes from the parent state of Parent to the parent state of TipSet
+type Change struct {
+	Store adt.Store
+	// Parent the tipset whose messages are executed
+	Parent *types.TipSet
+	// TipSet the child of Parent, whose parent state is the result of the execution
+	TipSet *types.TipSet
+	Pre    *state.StateTree
+	Post   *state.StateTree
+	// LoadMessages load the messages of Parent with their receipts, the events which are told
+	// by the messages are less precise without it
+	LoadMessages MessageLoader
+
+	changedOnce sync.Once
+	changed     map[address.Address]ActorChange
+	changedErr  error
+
+	newAddrsOnce sync.Once
+	newAddrs     []builtininit.AddressPair
+	newAddrsErr  error
+
+	msgsOnce sync.Once
+	msgs     []ExecutedMessage
+	msgsErr  error
+}
+
+// ExecutedMessage a top level message of Parent and the receipt of its execution
+type ExecutedMessage struct {
+	Message *types.Message
+	Receipt types.MessageReceipt
+}
+
+// MessageLoader load the executed messages of the change, in the order of the receipts
+type MessageLoader func(ctx context.Context) ([]ExecutedMessage, error)
+
+// ActorChange the actor before and after the change, nil means that the actor does not exist
+type ActorChange struct {
+	Pre  *types.Actor
+	Post *types.Actor
+}
+
+func NewChange(store adt.Store, parent, ts *types.TipSet) (*Change, error) {
+	pre, err := state.LoadStateTree(store, parent.ParentState())
+	if err != nil {
+		return nil, fmt.Errorf("load pre state tree: %w", err)
+	}
+	post, err := state.LoadStateTree(store, ts.ParentState())
+	if err != nil {
+		return nil, fmt.Errorf("load post state tree: %w", err)
+	}
+	return &Change{Store: store, Parent: parent, TipSet: ts, Pre: pre, Post: post}, nil
+}
+
+// Height the height of the events, it's the height of TipSet
+func (c *Change) Height() abi.ChainEpoch {
+	return c.TipSet.Height()
+}
+
+// StateRoot the state root after the change
+func (c *Change) StateRoot() cid.Cid {
+	return c.TipSet.ParentState()
+}
+
+// Actors get the actor of the address before and after the change,
+// nil means that the actor does not exist in the state.
+func (c *Change) Actors(addr address.Address) (pre, post *types.Actor, err error) {
+	if pre, err = getActor(c.Pre, addr); err != nil {
+		return nil, nil, fmt.Errorf("get pre actor %v: %w", addr.String(), err)
+	}
+	if post, err = getActor(c.Post, addr); err != nil {
+		return nil, nil, fmt.Errorf("get post actor %v: %w", addr.String(), err)
+	}
+	return pre, post, nil
+}
+
+func getActor(tree *state.StateTree, addr address.Address) (*types.Actor, error) {
+	act, err := tree.GetActor(addr)
+	if errors.Is(err, types.ErrActorNotFound) {
+		return nil, nil
+	}
+	return act, err
+}
+
+// ChangedActors get the actors which are created, modified or deleted by the change, the actors are keyed
+// by the ID address. It's computed once by diffing the actors HAMTs of the two states.
+func (c *Change) ChangedActors(ctx context.Context) (map[address.Address]ActorChange, error) {
+	c.changedOnce.Do(func() {
+		c.changed, c.changedErr = c.diffActors(ctx)
+	})
+	return c.changed, c.changedErr
+}
+
+func (c *Change) diffActors(ctx context.Context) (map[address.Address]ActorChange, error) {
+	preRoot, err := c.actorsRoot(ctx, c.Pre, c.Parent.ParentState())
+	if err != nil {
+		return nil, err
+	}
+	curRoot, err := c.actorsRoot(ctx, c.Post, c.TipSet.ParentState())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := hamt.Diff(ctx, c.Store, c.Store, preRoot, curRoot, hamt.UseTreeBitWidth(builtin7.DefaultHamtBitwidth))
+	if err != nil {
+		return nil, fmt.Errorf("diff actors: %w", err)
+	}
+	changed := make(map[address.Address]ActorChange, len(changes))
+	for _, change := range changes {
+		addr, err := address.NewFromBytes([]byte(change.Key))
+		if err != nil {
+			return nil, fmt.Errorf("address in state tree was not valid: %w", err)
+		}
+		var actorChange ActorChange
+		if change.Before != nil {
+			if actorChange.Pre, err = decodeActor(change.Before.Raw); err != nil {
+				return nil, err
+			}
+		}
+		if change.After != nil {
+			if actorChange.Post, err = decodeActor(change.After.Raw); err != nil {
+				return nil, err
+			}
+		}
+		changed[addr] = actorChange
+	}
+	return changed, nil
+}
+
+// actorsRoot the root of the actors HAMT, the state trees before v2 are not supported
+// because hamt/v3 can not read the nodes of the older HAMTs
+func (c *Change) actorsRoot(ctx context.Context, tree *state.StateTree, root cid.Cid) (cid.Cid, error) {
+	if tree.Version() < types.StateTreeVersion2 {
+		return cid.Undef, fmt.Errorf("state tree version %d is not supported", tree.Version())
+	}
+	var stateRoot types.StateRoot
+	if err := c.Store.Get(ctx, root, &stateRoot); err != nil {
+		return cid.Undef, fmt.Errorf("load state root %v: %w", root, err)
+	}
+	return stateRoot.Actors, nil
+}
+
+func decodeActor(raw []byte) (*types.Actor, error) {
+	var act types.Actor
+	if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("decode actor: %w", err)
+	}
+	return &act, nil
+}
+
+// NewAddresses get the robust addresses which get an ID address by the change, it's computed once
+func (c *Change) NewAddresses(ctx context.Context) ([]builtininit.AddressPair, error) {
+	c.newAddrsOnce.Do(func() {
+		c.newAddrs, c.newAddrsErr = c.diffAddressMap(ctx)
+	})
+	return c.newAddrs, c.newAddrsErr
+}
+
+func (c *Change) diffAddressMap(ctx context.Context) ([]builtininit.AddressPair, error) {
+	pre, post, err := c.Actors(builtininit.Address)
+	if err != nil {
+		return nil, err
+	}
+	if pre == nil || post == nil || pre.Head.Equals(post.Head) {
+		return nil, nil
+	}
+	preState, err := builtininit.Load(c.Store, pre)
+	if err != nil {
+		return nil, fmt.Errorf("load pre init state: %w", err)
+	}
+	curState, err := builtininit.Load(c.Store, post)
+	if err != nil {
+		return nil, fmt.Errorf("load init state: %w", err)
+	}
+	changes, err := builtininit.DiffAddressMap(ctx, c.Store, preState, curState)
+	if err != nil {
+		return nil, fmt.Errorf("diff address map: %w", err)
+	}
+	return changes.Added, nil
+}
+
+// Messages get the executed messages of Parent, it's loaded once. They are nil if LoadMessages is not set.
+func (c *Change) Messages(ctx context.Context) ([]ExecutedMessage, error) {
+	if c.LoadMessages == nil {
+		return nil, nil
+	}
+	c.msgsOnce.Do(func() {
+		c.msgs, c.msgsErr = c.LoadMessages(ctx)
+	})
+	return c.msgs, c.msgsErr
+}
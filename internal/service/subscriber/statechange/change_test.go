@@ -0,0 +1,70 @@
+package statechange
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/adt"
+)
+
+func TestChange_ChangedActors(t *testing.T) {
+	ctx := context.Background()
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewMemory()))
+	tree, err := state.NewStateTree(store, types.StateTreeVersion4)
+	assert.Nil(t, err)
+
+	kept, _ := address.NewIDAddress(100)     //nolint:errcheck
+	modified, _ := address.NewIDAddress(101) //nolint:errcheck
+	created, _ := address.NewIDAddress(102)  //nolint:errcheck
+	newActor := func(balance int64) *types.Actor {
+		return &types.Actor{Code: builtin7.AccountActorCodeID, Head: builtin7.AccountActorCodeID, Balance: big.NewInt(balance)}
+	}
+	assert.Nil(t, tree.SetActor(kept, newActor(1)))
+	assert.Nil(t, tree.SetActor(modified, newActor(1)))
+	preRoot, err := tree.Flush(ctx)
+	assert.Nil(t, err)
+
+	assert.Nil(t, tree.SetActor(modified, newActor(2)))
+	assert.Nil(t, tree.SetActor(created, newActor(3)))
+	curRoot, err := tree.Flush(ctx)
+	assert.Nil(t, err)
+
+	parentBlk := mock.MkBlock(nil, 1, 1)
+	parentBlk.ParentStateRoot = preRoot
+	parent := mock.TipSet(parentBlk)
+	blk := mock.MkBlock(parent, 1, 2)
+	blk.ParentStateRoot = curRoot
+	ts := mock.TipSet(blk)
+
+	change, err := NewChange(store, parent, ts)
+	assert.Nil(t, err)
+	assert.Equal(t, curRoot, change.StateRoot())
+
+	changed, err := change.ChangedActors(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changed))
+	assert.Equal(t, big.NewInt(1), changed[modified].Pre.Balance)
+	assert.Equal(t, big.NewInt(2), changed[modified].Post.Balance)
+	assert.Nil(t, changed[created].Pre)
+	assert.Equal(t, big.NewInt(3), changed[created].Post.Balance)
+
+	pre, post, err := change.Actors(created)
+	assert.Nil(t, err)
+	assert.Nil(t, pre)
+	assert.NotNil(t, post)
+
+	// there is no init actor in the states
+	pairs, err := change.NewAddresses(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, pairs)
+}
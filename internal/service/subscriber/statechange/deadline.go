@@ -0,0 +1,290 @@
+package statechange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/miner"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// the partition events of the Window PoSt deadlines
+const (
+	_ = iota
+	PartitionFaulted
+	PartitionRecovering
+	PartitionRecovered
+	PartitionExpired
+	PartitionTerminated
+)
+
+var partitionEventNames = map[int]string{
+	PartitionFaulted:    "PARTITION_FAULTED",
+	PartitionRecovering: "PARTITION_RECOVERING",
+	PartitionRecovered:  "PARTITION_RECOVERED",
+	PartitionExpired:    "PARTITION_EXPIRED",
+	PartitionTerminated: "PARTITION_TERMINATED",
+}
+
+// the reminders of the Window PoSt deadlines
+const (
+	_ = iota
+	DeadlineOpened
+	DeadlineClosing
+)
+
+var deadlineReminderNames = map[int]string{
+	DeadlineOpened:  "DEADLINE_OPENED",
+	DeadlineClosing: "DEADLINE_CLOSING",
+}
+
+// DeadlineClosingEpochs how many epochs before the deadline closes the closing reminder is sent
+var DeadlineClosingEpochs abi.ChainEpoch = 10
+
+// PartitionEvent the sectors of a partition which are faulted, recovering, recovered or removed by the change
+type PartitionEvent struct {
+	MinerID   address.Address `json:"miner_id"`
+	Deadline  uint64          `json:"deadline"`
+	Partition uint64          `json:"partition"`
+	Event     int             `json:"event"`
+	// EventName the name of Event, e.g. PARTITION_FAULTED
+	EventName string   `json:"event_name"`
+	Count     uint64   `json:"count"`
+	Sectors   []uint64 `json:"sectors"`
+}
+
+// DeadlineReminder the Window PoSt deadline of the miner is opened or about to close
+type DeadlineReminder struct {
+	MinerID address.Address `json:"miner_id"`
+	Event   int             `json:"event"`
+	// EventName the name of Event, e.g. DEADLINE_CLOSING
+	EventName   string         `json:"event_name"`
+	Deadline    uint64         `json:"deadline"`
+	Open        abi.ChainEpoch `json:"open"`
+	Close       abi.ChainEpoch `json:"close"`
+	FaultCutoff abi.ChainEpoch `json:"fault_cutoff"`
+	// Partitions and PostedPartitions, the deadline is missed if not all the partitions are posted when it closes
+	Partitions       uint64 `json:"partitions"`
+	PostedPartitions uint64 `json:"posted_partitions"`
+	LiveSectors      uint64 `json:"live_sectors"`
+	FaultySectors    uint64 `json:"faulty_sectors"`
+}
+
+func init() {
+	Register(model.DeadlineKind, ExtractorFunc(ExtractPartitionEvents))
+	Register(model.DeadlineReminderKind, ExtractorFunc(ExtractDeadlineReminders))
+}
+
+// ExtractPartitionEvents extract the partition events of the miners,
+// the addresses which are not miners are ignored
+func ExtractPartitionEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	var events []*Event
+	for _, addr := range addrs {
+		pre, post, err := change.Actors(addr)
+		if err != nil {
+			return nil, err
+		}
+		if pre == nil || post == nil || !builtin.IsStorageMinerActor(post.Code) {
+			continue
+		}
+		if pre.Head.Equals(post.Head) {
+			continue
+		}
+		preState, err := miner.Load(change.Store, pre)
+		if err != nil {
+			return nil, fmt.Errorf("load pre miner state %v: %w", addr.String(), err)
+		}
+		curState, err := miner.Load(change.Store, post)
+		if err != nil {
+			return nil, fmt.Errorf("load miner state %v: %w", addr.String(), err)
+		}
+		partEvents, err := DiffPartitionEvents(change.Height(), addr, preState, curState)
+		if err != nil {
+			log.Errorf("[ExtractPartitionEvents] diff miner %v at %d err:%v", addr.String(), change.Height(), err)
+			continue
+		}
+		for _, partEvent := range partEvents {
+			events = append(events, NewEvent(model.DeadlineKind, change, addr, partEvent))
+		}
+	}
+	return events, nil
+}
+
+// DiffPartitionEvents get the partition events of the miner from pre to cur
+func DiffPartitionEvents(height abi.ChainEpoch, minerID address.Address, pre, cur miner.State) ([]*PartitionEvent, error) {
+	deadlines, err := miner.DiffDeadlines(pre, cur)
+	if err != nil {
+		return nil, fmt.Errorf("diff deadlines: %w", err)
+	}
+	removed, err := loadRemovedSectors(pre, deadlines)
+	if err != nil {
+		return nil, fmt.Errorf("load removed sectors: %w", err)
+	}
+	return partitionDiffEvents(minerID, height, deadlines, removed)
+}
+
+// partitionDiffEvents one event for each kind of the changed sectors of a partition,
+// the removed sectors are split into expired and terminated like partitionEvents
+func partitionDiffEvents(minerID address.Address, height abi.ChainEpoch, deadlines miner.DeadlinesDiff,
+	removed map[abi.SectorNumber]*miner.SectorOnChainInfo) (events []*PartitionEvent, err error) {
+	err = forEachPartitionDiff(deadlines, func(dlIdx, partIdx uint64, part *miner.PartitionDiff) error {
+		appendEvent := func(event int, sectors []uint64) {
+			if len(sectors) == 0 {
+				return
+			}
+			events = append(events, &PartitionEvent{
+				MinerID:   minerID,
+				Deadline:  dlIdx,
+				Partition: partIdx,
+				Event:     event,
+				EventName: partitionEventNames[event],
+				Count:     uint64(len(sectors)),
+				Sectors:   sectors,
+			})
+		}
+
+		var expired, terminated []uint64
+		if err := part.Removed.ForEach(func(sno uint64) error {
+			if info, ok := removed[abi.SectorNumber(sno)]; ok && info.Expiration <= height {
+				expired = append(expired, sno)
+			} else {
+				terminated = append(terminated, sno)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, item := range []struct {
+			event int
+			bf    bitfield.BitField
+		}{
+			{PartitionFaulted, part.Faulted},
+			{PartitionRecovering, part.Recovering},
+			{PartitionRecovered, part.Recovered},
+		} {
+			sectors, err := item.bf.All(uint64(miner.AddressedSectorsMax))
+			if err != nil {
+				return err
+			}
+			appendEvent(item.event, sectors)
+		}
+		appendEvent(PartitionExpired, expired)
+		appendEvent(PartitionTerminated, terminated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ExtractDeadlineReminders remind the subscribed miners when the current deadline is opened
+// and DeadlineClosingEpochs before it closes, the null rounds between the parent and the tipset are counted in
+func ExtractDeadlineReminders(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	var events []*Event
+	for _, addr := range addrs {
+		_, post, err := change.Actors(addr)
+		if err != nil {
+			return nil, err
+		}
+		if post == nil || !builtin.IsStorageMinerActor(post.Code) {
+			continue
+		}
+		state, err := miner.Load(change.Store, post)
+		if err != nil {
+			return nil, fmt.Errorf("load miner state %v: %w", addr.String(), err)
+		}
+		info, err := state.DeadlineInfo(change.Height())
+		if err != nil {
+			return nil, fmt.Errorf("load deadline info %v: %w", addr.String(), err)
+		}
+		info = info.NextNotElapsed()
+		event := reminderEvent(change.Parent.Height(), change.Height(), info)
+		if event == 0 {
+			continue
+		}
+		reminder, err := NewDeadlineReminder(addr, state, info, event)
+		if err != nil {
+			log.Errorf("[ExtractDeadlineReminders] load deadline of miner %v at %d err:%v",
+				addr.String(), change.Height(), err)
+			continue
+		}
+		// nothing to prove in the deadline
+		if reminder.Partitions == 0 {
+			continue
+		}
+		events = append(events, NewEvent(model.DeadlineReminderKind, change, addr, reminder))
+	}
+	return events, nil
+}
+
+// reminderEvent which reminder is due in the epochs (from, to], 0 means none
+func reminderEvent(from, to abi.ChainEpoch, info *dline.Info) int {
+	due := func(epoch abi.ChainEpoch) bool {
+		return from < epoch && epoch <= to
+	}
+	switch {
+	case due(info.Close - DeadlineClosingEpochs):
+		return DeadlineClosing
+	case due(info.Open):
+		return DeadlineOpened
+	}
+	return 0
+}
+
+func NewDeadlineReminder(minerID address.Address, state miner.State, info *dline.Info, event int) (*DeadlineReminder, error) {
+	dl, err := state.LoadDeadline(info.Index)
+	if err != nil {
+		return nil, err
+	}
+	reminder := &DeadlineReminder{
+		MinerID:     minerID,
+		Event:       event,
+		EventName:   deadlineReminderNames[event],
+		Deadline:    info.Index,
+		Open:        info.Open,
+		Close:       info.Close,
+		FaultCutoff: info.FaultCutoff,
+	}
+	if err = dl.ForEachPartition(func(_ uint64, part miner.Partition) error {
+		reminder.Partitions++
+		live, err := part.LiveSectors()
+		if err != nil {
+			return err
+		}
+		if reminder.LiveSectors, err = addCount(reminder.LiveSectors, live); err != nil {
+			return err
+		}
+		faulty, err := part.FaultySectors()
+		if err != nil {
+			return err
+		}
+		reminder.FaultySectors, err = addCount(reminder.FaultySectors, faulty)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	posted, err := dl.PartitionsPoSted()
+	if err != nil {
+		return nil, err
+	}
+	if reminder.PostedPartitions, err = posted.Count(); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func addCount(n uint64, bf bitfield.BitField) (uint64, error) {
+	count, err := bf.Count()
+	if err != nil {
+		return 0, err
+	}
+	return n + count, nil
+}
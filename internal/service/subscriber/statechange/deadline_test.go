@@ -0,0 +1,85 @@
+package statechange
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/miner"
+)
+
+func TestPartitionDiffEvents(t *testing.T) {
+	deadlines := miner.DeadlinesDiff{
+		3: miner.DeadlineDiff{
+			0: &miner.PartitionDiff{
+				Removed:    bitfield.NewFromSet([]uint64{7, 8}),
+				Recovered:  bitfield.New(),
+				Faulted:    bitfield.NewFromSet([]uint64{9, 10}),
+				Recovering: bitfield.New(),
+			},
+		},
+		1: miner.DeadlineDiff{
+			2: &miner.PartitionDiff{
+				Removed:    bitfield.New(),
+				Recovered:  bitfield.NewFromSet([]uint64{20}),
+				Faulted:    bitfield.New(),
+				Recovering: bitfield.NewFromSet([]uint64{21}),
+			},
+		},
+	}
+	removed := map[abi.SectorNumber]*miner.SectorOnChainInfo{
+		7: {SectorNumber: 7, Expiration: 100},
+		8: {SectorNumber: 8, Expiration: 500},
+	}
+	events, err := partitionDiffEvents(testMiner, 100, deadlines, removed)
+	assert.Nil(t, err)
+
+	type want struct {
+		deadline, partition uint64
+		event               int
+		sectors             []uint64
+	}
+	wants := []want{
+		{1, 2, PartitionRecovering, []uint64{21}},
+		{1, 2, PartitionRecovered, []uint64{20}},
+		{3, 0, PartitionFaulted, []uint64{9, 10}},
+		{3, 0, PartitionExpired, []uint64{7}},
+		{3, 0, PartitionTerminated, []uint64{8}},
+	}
+	if assert.Len(t, events, len(wants)) {
+		for i, w := range wants {
+			e := events[i]
+			assert.Equal(t, testMiner, e.MinerID)
+			assert.Equal(t, w.deadline, e.Deadline)
+			assert.Equal(t, w.partition, e.Partition)
+			assert.Equal(t, w.event, e.Event)
+			assert.Equal(t, partitionEventNames[w.event], e.EventName)
+			assert.Equal(t, w.sectors, e.Sectors)
+			assert.Equal(t, uint64(len(w.sectors)), e.Count)
+		}
+	}
+}
+
+func TestReminderEvent(t *testing.T) {
+	info := &dline.Info{Open: 100, Close: 160}
+	tests := []struct {
+		name     string
+		from, to abi.ChainEpoch
+		want     int
+	}{
+		{"opened", 99, 100, DeadlineOpened},
+		{"opened after null rounds", 98, 101, DeadlineOpened},
+		{"already opened", 100, 101, 0},
+		{"closing", 149, 150, DeadlineClosing},
+		{"closing after null rounds", 140, 155, DeadlineClosing},
+		{"closing reminded", 150, 151, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, reminderEvent(tt.from, tt.to, info))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package statechange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/market"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// the storage deal events
+const (
+	_ = iota
+	DealPublished
+	DealActivated
+	DealSlashed
+	DealExpired
+)
+
+var dealEventNames = map[int]string{
+	DealPublished: "DEAL_PUBLISHED",
+	DealActivated: "DEAL_ACTIVATED",
+	DealSlashed:   "DEAL_SLASHED",
+	DealExpired:   "DEAL_EXPIRED",
+}
+
+// DealEvent a storage deal event, it's sent to the subscribers of the client and the provider
+type DealEvent struct {
+	DealID    abi.DealID          `json:"deal_id"`
+	Event     int                 `json:"event"`
+	EventName string              `json:"event_name"`
+	Proposal  market.DealProposal `json:"proposal"`
+	// State nil if the deal has never been activated
+	State *market.DealState `json:"state,omitempty"`
+}
+
+func NewDealEvent(dealID abi.DealID, event int, proposal market.DealProposal, state *market.DealState) *DealEvent {
+	return &DealEvent{
+		DealID:    dealID,
+		Event:     event,
+		EventName: dealEventNames[event],
+		Proposal:  proposal,
+		State:     state,
+	}
+}
+
+func init() {
+	Register(model.DealKind, ExtractorFunc(ExtractDealEvents))
+}
+
+// ExtractDealEvents extract the events of the deals whose client or provider is subscribed
+func ExtractDealEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	pre, post, err := change.Actors(market.Address)
+	if err != nil {
+		return nil, err
+	}
+	if pre == nil || post == nil || pre.Head.Equals(post.Head) {
+		return nil, nil
+	}
+	preState, err := market.Load(change.Store, pre)
+	if err != nil {
+		return nil, fmt.Errorf("load pre market state: %w", err)
+	}
+	curState, err := market.Load(change.Store, post)
+	if err != nil {
+		return nil, fmt.Errorf("load market state: %w", err)
+	}
+
+	var (
+		proposalChanges = new(market.DealProposalChanges)
+		stateChanges    = new(market.DealStateChanges)
+	)
+	if changed, err := preState.ProposalsChanged(curState); err != nil {
+		return nil, err
+	} else if changed {
+		if proposalChanges, err = market.DiffDealProposals(ctx, change.Store, preState, curState); err != nil {
+			return nil, fmt.Errorf("diff deal proposals: %w", err)
+		}
+	}
+	if changed, err := preState.StatesChanged(curState); err != nil {
+		return nil, err
+	} else if changed {
+		if stateChanges, err = market.DiffDealStates(ctx, change.Store, preState, curState); err != nil {
+			return nil, fmt.Errorf("diff deal states: %w", err)
+		}
+	}
+	proposals, err := curState.Proposals()
+	if err != nil {
+		return nil, err
+	}
+	dealEvents, err := diffDealEvents(change.Parent.Height(), proposalChanges, stateChanges,
+		func(id abi.DealID) (*market.DealProposal, error) {
+			proposal, found, err := proposals.Get(id)
+			if err == nil && !found {
+				err = fmt.Errorf("deal %d not found", id)
+			}
+			return proposal, err
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	subscribed := addressSet(addrs)
+	var events []*Event
+	for _, dealEvent := range dealEvents {
+		routes := []address.Address{dealEvent.Proposal.Client, dealEvent.Proposal.Provider}
+		if !anySubscribed(subscribed, routes) {
+			continue
+		}
+		event := NewEvent(model.DealKind, change, market.Address, dealEvent)
+		event.Routes = routes
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// diffDealEvents get the deal events from the changes, execHeight is the height the change is made at,
+// getProposal get the proposal of the deal whose proposal is not changed
+func diffDealEvents(execHeight abi.ChainEpoch, proposals *market.DealProposalChanges, states *market.DealStateChanges,
+	getProposal func(abi.DealID) (*market.DealProposal, error)) (events []*DealEvent, err error) {
+	added := make(map[abi.DealID]market.DealProposal, len(proposals.Added))
+	for _, p := range proposals.Added {
+		added[p.ID] = p.Proposal
+		events = append(events, NewDealEvent(p.ID, DealPublished, p.Proposal, nil))
+	}
+	proposalOf := func(id abi.DealID) (market.DealProposal, error) {
+		if p, ok := added[id]; ok {
+			return p, nil
+		}
+		p, err := getProposal(id)
+		if err != nil {
+			return market.DealProposal{}, err
+		}
+		return *p, nil
+	}
+
+	for _, s := range states.Added {
+		proposal, err := proposalOf(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		state := s.Deal
+		events = append(events, NewDealEvent(s.ID, DealActivated, proposal, &state))
+	}
+	for _, s := range states.Modified {
+		if s.From.SlashEpoch != -1 || s.To.SlashEpoch == -1 {
+			continue
+		}
+		proposal, err := proposalOf(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, NewDealEvent(s.ID, DealSlashed, proposal, s.To))
+	}
+
+	// the deal is removed when it expires or after it is slashed
+	removed := make(map[abi.DealID]market.DealState, len(states.Removed))
+	for _, s := range states.Removed {
+		removed[s.ID] = s.Deal
+	}
+	for _, p := range proposals.Removed {
+		state, activated := removed[p.ID]
+		if !activated {
+			// the deal is never activated before the start epoch
+			events = append(events, NewDealEvent(p.ID, DealExpired, p.Proposal, nil))
+			continue
+		}
+		switch {
+		case state.SlashEpoch == -1:
+			events = append(events, NewDealEvent(p.ID, DealExpired, p.Proposal, &state))
+		case state.SlashEpoch >= execHeight:
+			// slashed and removed by the same change, the slash is not seen by the modified states
+			events = append(events, NewDealEvent(p.ID, DealSlashed, p.Proposal, &state))
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,70 @@
+package statechange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/market"
+)
+
+func TestDiffDealEvents(t *testing.T) {
+	client, _ := address.NewIDAddress(100)   //nolint:errcheck
+	provider, _ := address.NewIDAddress(200) //nolint:errcheck
+	proposal := market.DealProposal{Client: client, Provider: provider}
+	existing := map[abi.DealID]*market.DealProposal{2: &proposal, 3: &proposal}
+	getProposal := func(id abi.DealID) (*market.DealProposal, error) {
+		if p, ok := existing[id]; ok {
+			return p, nil
+		}
+		return nil, errors.New("not found")
+	}
+
+	proposals := &market.DealProposalChanges{
+		Added: []market.ProposalIDState{{ID: 1, Proposal: proposal}},
+		Removed: []market.ProposalIDState{
+			{ID: 4, Proposal: proposal}, {ID: 5, Proposal: proposal},
+			{ID: 6, Proposal: proposal}, {ID: 7, Proposal: proposal},
+		},
+	}
+	states := &market.DealStateChanges{
+		// deal 1 is published and activated by the same change
+		Added: []market.DealIDState{
+			{ID: 1, Deal: market.DealState{SectorStartEpoch: 100, LastUpdatedEpoch: -1, SlashEpoch: -1}},
+			{ID: 2, Deal: market.DealState{SectorStartEpoch: 100, LastUpdatedEpoch: -1, SlashEpoch: -1}},
+		},
+		Modified: []market.DealStateChange{
+			{ID: 3, From: &market.DealState{SlashEpoch: -1}, To: &market.DealState{SlashEpoch: 100}},
+			{ID: 2, From: &market.DealState{LastUpdatedEpoch: 10, SlashEpoch: -1},
+				To: &market.DealState{LastUpdatedEpoch: 90, SlashEpoch: -1}},
+		},
+		// deal 4 is never activated, deal 5 expired, deal 6 slashed before, deal 7 slashed now
+		Removed: []market.DealIDState{
+			{ID: 5, Deal: market.DealState{SlashEpoch: -1}},
+			{ID: 6, Deal: market.DealState{SlashEpoch: 50}},
+			{ID: 7, Deal: market.DealState{SlashEpoch: 100}},
+		},
+	}
+
+	events, err := diffDealEvents(100, proposals, states, getProposal)
+	assert.Nil(t, err)
+	var got [][2]int
+	for _, e := range events {
+		got = append(got, [2]int{int(e.DealID), e.Event})
+		assert.Equal(t, client, e.Proposal.Client)
+		assert.Equal(t, dealEventNames[e.Event], e.EventName)
+	}
+	assert.Equal(t, [][2]int{
+		{1, DealPublished}, {1, DealActivated}, {2, DealActivated}, {3, DealSlashed},
+		{4, DealExpired}, {5, DealExpired}, {7, DealSlashed},
+	}, got)
+
+	// the proposal of an activated deal is missing
+	_, err = diffDealEvents(100, &market.DealProposalChanges{}, &market.DealStateChanges{
+		Added: []market.DealIDState{{ID: 9}},
+	}, getProposal)
+	assert.NotNil(t, err)
+}
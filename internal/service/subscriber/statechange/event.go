@@ -0,0 +1,98 @@
+package statechange
+
+import (
+	"context"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+)
+
+// Event a state change of an actor, it's sent to the apps who subscribe the kind of the address
+type Event struct {
+	Kind      string          `json:"kind"`
+	Height    abi.ChainEpoch  `json:"height"`
+	TipSet    types.TipSetKey `json:"tipset"`
+	StateRoot cid.Cid         `json:"state_root"`
+	// Address the actor whose state changed
+	Address address.Address `json:"address"`
+	// Data the detail of the event, e.g. *SectorEvent
+	Data interface{} `json:"data"`
+	// Routes the addresses whose subscribers receive the event, Address is used if it is empty,
+	// e.g. a deal event of the market actor is routed to the client and the provider
+	Routes []address.Address `json:"-"`
+}
+
+func NewEvent(kind string, change *Change, addr address.Address, data interface{}) *Event {
+	return &Event{
+		Kind:      kind,
+		Height:    change.Height(),
+		TipSet:    change.TipSet.Key(),
+		StateRoot: change.StateRoot(),
+		Address:   addr,
+		Data:      data,
+	}
+}
+
+// Targets the addresses whose subscribers receive the event
+func (e *Event) Targets() []address.Address {
+	if len(e.Routes) > 0 {
+		return e.Routes
+	}
+	return []address.Address{e.Address}
+}
+
+// Extractor extract the events of a kind from the state change
+type Extractor interface {
+	// Extract get the events of the subscribed addresses
+	Extract(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error)
+}
+
+type ExtractorFunc func(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error)
+
+func (f ExtractorFunc) Extract(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	return f(ctx, change, addrs)
+}
+
+// extractors kind -> Extractor, they are registered by init
+var extractors = map[string]Extractor{}
+
+// Register register the extractor of the kind, it's not safe for concurrent use and should be called by init
+func Register(kind string, extractor Extractor) {
+	extractors[kind] = extractor
+}
+
+func Lookup(kind string) (Extractor, bool) {
+	extractor, ok := extractors[kind]
+	return extractor, ok
+}
+
+// Kinds the kinds which have an extractor
+func Kinds() []string {
+	kinds := make([]string, 0, len(extractors))
+	for kind := range extractors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func addressSet(addrs []address.Address) map[address.Address]struct{} {
+	set := make(map[address.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// anySubscribed whether any of the addresses is in the subscribed set
+func anySubscribed(subscribed map[address.Address]struct{}, addrs []address.Address) bool {
+	for _, addr := range addrs {
+		if _, ok := subscribed[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
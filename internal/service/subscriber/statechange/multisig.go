@@ -0,0 +1,217 @@
+package statechange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/multisig"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// the multisig pending transaction events
+const (
+	_ = iota
+	MultisigProposed
+	MultisigApproved
+	MultisigExecuted
+	MultisigCancelled
+	// MultisigRemoved the transaction is removed, but it's unknown whether it's executed or cancelled
+	MultisigRemoved
+)
+
+var multisigEventNames = map[int]string{
+	MultisigProposed:  "PROPOSED",
+	MultisigApproved:  "APPROVED",
+	MultisigExecuted:  "EXECUTED",
+	MultisigCancelled: "CANCELLED",
+	MultisigRemoved:   "REMOVED",
+}
+
+// MultisigEvent a pending transaction event of the multisig,
+// it's sent to the subscribers of the multisig and its signers
+type MultisigEvent struct {
+	Multisig    address.Address      `json:"multisig"`
+	TxID        int64                `json:"tx_id"`
+	Event       int                  `json:"event"`
+	EventName   string               `json:"event_name"`
+	Transaction multisig.Transaction `json:"transaction"`
+	// Approver the signer who proposed or approved the transaction
+	Approver  *address.Address `json:"approver,omitempty"`
+	Threshold uint64           `json:"threshold"`
+	// RemainingApprovals how many approvals are still needed to execute the transaction
+	RemainingApprovals uint64 `json:"remaining_approvals"`
+	// PendingSigners the signers who have not approved the transaction yet
+	PendingSigners []address.Address `json:"pending_signers,omitempty"`
+}
+
+func init() {
+	Register(model.MultisigKind, ExtractorFunc(ExtractMultisigEvents))
+}
+
+// ExtractMultisigEvents extract the pending transaction events of the multisigs changed by the change,
+// which are subscribed or have a subscribed signer
+func ExtractMultisigEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	subscribed := addressSet(addrs)
+	changed, err := change.ChangedActors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events   []*Event
+		outcomes map[address.Address]map[int64]int
+	)
+	for addr, actorChange := range changed {
+		pre, post := actorChange.Pre, actorChange.Post
+		// a multisig created by the change has no pending transactions
+		if pre == nil || post == nil || !builtin.IsMultisigActor(post.Code) {
+			continue
+		}
+		preState, err := multisig.Load(change.Store, pre)
+		if err != nil {
+			return nil, fmt.Errorf("load pre multisig state %v: %w", addr.String(), err)
+		}
+		curState, err := multisig.Load(change.Store, post)
+		if err != nil {
+			return nil, fmt.Errorf("load multisig state %v: %w", addr.String(), err)
+		}
+		if changed, err := preState.PendingTxnChanged(curState); err != nil || !changed {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		signers, err := curState.Signers()
+		if err != nil {
+			return nil, err
+		}
+		routes := append([]address.Address{addr}, signers...)
+		if !anySubscribed(subscribed, routes) {
+			continue
+		}
+		threshold, err := curState.Threshold()
+		if err != nil {
+			return nil, err
+		}
+		txChanges, err := multisig.DiffPendingTransactions(ctx, change.Store, preState, curState)
+		if err != nil {
+			return nil, fmt.Errorf("diff pending transactions of %v: %w", addr.String(), err)
+		}
+		if len(txChanges.Removed) > 0 && outcomes == nil {
+			if outcomes, err = multisigOutcomes(ctx, change); err != nil {
+				return nil, err
+			}
+		}
+		for _, msigEvent := range diffMultisigEvents(addr, threshold, signers, txChanges, outcomes[addr]) {
+			event := NewEvent(model.MultisigKind, change, addr, msigEvent)
+			event.Routes = routes
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// multisigOutcomes get how the pending transactions are removed by the Approve and Cancel messages
+// of the change, keyed by the ID address of the multisig and the transaction ID.
+// Only the top level messages are looked at, the transactions removed by the internal calls are unknown.
+func multisigOutcomes(ctx context.Context, change *Change) (map[address.Address]map[int64]int, error) {
+	msgs, err := change.Messages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load messages: %w", err)
+	}
+	outcomes := make(map[address.Address]map[int64]int)
+	for _, executed := range msgs {
+		msg := executed.Message
+		if executed.Receipt.ExitCode.IsError() ||
+			(msg.Method != multisig.Methods.Approve && msg.Method != multisig.Methods.Cancel) {
+			continue
+		}
+		var params multisig.TxnIDParams
+		if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+			continue
+		}
+		event := MultisigCancelled
+		if msg.Method == multisig.Methods.Approve {
+			var ret multisig.ApproveReturn
+			if err := ret.UnmarshalCBOR(bytes.NewReader(executed.Receipt.Return)); err != nil || !ret.Applied {
+				// the approval doesn't execute the transaction, it's still pending
+				continue
+			}
+			event = MultisigExecuted
+		}
+		// the message may be sent to the robust address of the multisig
+		msig, err := change.Pre.LookupID(msg.To)
+		if err != nil {
+			continue
+		}
+		if outcomes[msig] == nil {
+			outcomes[msig] = make(map[int64]int)
+		}
+		outcomes[msig][int64(params.ID)] = event
+	}
+	return outcomes, nil
+}
+
+// diffMultisigEvents get the events from the pending transaction changes. A removed transaction
+// is executed or cancelled by the outcome of its message, it's only removed if the outcome is unknown.
+func diffMultisigEvents(msig address.Address, threshold uint64, signers []address.Address,
+	changes *multisig.PendingTransactionChanges, outcomes map[int64]int) (events []*MultisigEvent) {
+	newEvent := func(txID int64, event int, tx multisig.Transaction, approver *address.Address) *MultisigEvent {
+		msigEvent := &MultisigEvent{
+			Multisig:    msig,
+			TxID:        txID,
+			Event:       event,
+			EventName:   multisigEventNames[event],
+			Transaction: tx,
+			Approver:    approver,
+			Threshold:   threshold,
+		}
+		if event == MultisigProposed || event == MultisigApproved {
+			if approved := uint64(len(tx.Approved)); approved < threshold {
+				msigEvent.RemainingApprovals = threshold - approved
+			}
+			msigEvent.PendingSigners = pendingSigners(signers, tx.Approved)
+		}
+		return msigEvent
+	}
+
+	for _, added := range changes.Added {
+		var proposer *address.Address
+		if len(added.Tx.Approved) > 0 {
+			proposer = &added.Tx.Approved[0]
+		}
+		events = append(events, newEvent(added.TxID, MultisigProposed, added.Tx, proposer))
+	}
+	for _, modified := range changes.Modified {
+		for _, approver := range pendingSigners(modified.To.Approved, modified.From.Approved) {
+			approver := approver
+			events = append(events, newEvent(modified.TxID, MultisigApproved, modified.To, &approver))
+		}
+	}
+	for _, removed := range changes.Removed {
+		event, ok := outcomes[removed.TxID]
+		if !ok {
+			event = MultisigRemoved
+		}
+		events = append(events, newEvent(removed.TxID, event, removed.Tx, nil))
+	}
+	return
+}
+
+// pendingSigners the signers who are not in approved
+func pendingSigners(signers, approved []address.Address) (pending []address.Address) {
+	approvedSet := make(map[address.Address]struct{}, len(approved))
+	for _, a := range approved {
+		approvedSet[a] = struct{}{}
+	}
+	for _, signer := range signers {
+		if _, ok := approvedSet[signer]; !ok {
+			pending = append(pending, signer)
+		}
+	}
+	return
+}
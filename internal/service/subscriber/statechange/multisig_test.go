@@ -0,0 +1,122 @@
+package statechange
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/bitrainforest/pulsar/chain/actors/adt"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/multisig"
+)
+
+func TestDiffMultisigEvents(t *testing.T) {
+	msig, _ := address.NewIDAddress(1000) //nolint:errcheck
+	var signers []address.Address
+	for i := uint64(1); i <= 3; i++ {
+		signer, _ := address.NewIDAddress(100 + i) //nolint:errcheck
+		signers = append(signers, signer)
+	}
+	tx := func(approved ...address.Address) multisig.Transaction {
+		return multisig.Transaction{To: msig, Approved: approved}
+	}
+
+	changes := &multisig.PendingTransactionChanges{
+		Added: []multisig.TransactionChange{{TxID: 1, Tx: tx(signers[0])}},
+		Modified: []multisig.TransactionModification{
+			{TxID: 2, From: tx(signers[0]), To: tx(signers[0], signers[1])},
+		},
+		// tx 3 got the last approval, tx 4 is cancelled by the proposer, how tx 5 is removed is unknown
+		Removed: []multisig.TransactionChange{
+			{TxID: 3, Tx: tx(signers[0], signers[2])},
+			{TxID: 4, Tx: tx(signers[1])},
+			{TxID: 5, Tx: tx(signers[2])},
+		},
+	}
+	outcomes := map[int64]int{3: MultisigExecuted, 4: MultisigCancelled}
+	events := diffMultisigEvents(msig, 3, signers, changes, outcomes)
+	assert.Equal(t, 5, len(events))
+
+	assert.Equal(t, MultisigProposed, events[0].Event)
+	assert.Equal(t, signers[0], *events[0].Approver)
+	assert.Equal(t, uint64(2), events[0].RemainingApprovals)
+	assert.Equal(t, signers[1:], events[0].PendingSigners)
+
+	assert.Equal(t, MultisigApproved, events[1].Event)
+	assert.Equal(t, int64(2), events[1].TxID)
+	assert.Equal(t, signers[1], *events[1].Approver)
+	assert.Equal(t, uint64(1), events[1].RemainingApprovals)
+	assert.Equal(t, []address.Address{signers[2]}, events[1].PendingSigners)
+
+	assert.Equal(t, MultisigExecuted, events[2].Event)
+	assert.Equal(t, "EXECUTED", events[2].EventName)
+	assert.Nil(t, events[2].PendingSigners)
+	assert.Equal(t, MultisigCancelled, events[3].Event)
+	assert.Equal(t, MultisigRemoved, events[4].Event)
+	assert.Equal(t, "REMOVED", events[4].EventName)
+	for _, e := range events {
+		assert.Equal(t, msig, e.Multisig)
+		assert.Equal(t, uint64(3), e.Threshold)
+	}
+}
+
+func TestMultisigOutcomes(t *testing.T) {
+	ctx := context.Background()
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewMemory()))
+	tree, err := state.NewStateTree(store, types.StateTreeVersion4)
+	assert.Nil(t, err)
+
+	msig, _ := address.NewIDAddress(1000)   //nolint:errcheck
+	signer1, _ := address.NewIDAddress(101) //nolint:errcheck
+	signer2, _ := address.NewIDAddress(102) //nolint:errcheck
+	marshal := func(v cbg.CBORMarshaler) []byte {
+		buf := new(bytes.Buffer)
+		assert.Nil(t, v.MarshalCBOR(buf))
+		return buf.Bytes()
+	}
+	executed := func(method abi.MethodNum, params multisig.TxnIDParams, code exitcode.ExitCode, ret []byte) ExecutedMessage {
+		return ExecutedMessage{
+			Message: &types.Message{To: msig, Method: method, Params: marshal(&params)},
+			Receipt: types.MessageReceipt{ExitCode: code, Return: ret},
+		}
+	}
+	applied := marshal(&multisig.ApproveReturn{Applied: true})
+	pending := marshal(&multisig.ApproveReturn{Applied: false})
+
+	change := &Change{Store: store, Pre: tree, LoadMessages: func(ctx context.Context) ([]ExecutedMessage, error) {
+		return []ExecutedMessage{
+			// the last approval executes tx 1
+			executed(multisig.Methods.Approve, multisig.TxnIDParams{ID: 1}, exitcode.Ok, applied),
+			// tx 2 is cancelled although it has enough approvals with one more
+			executed(multisig.Methods.Cancel, multisig.TxnIDParams{ID: 2}, exitcode.Ok, nil),
+			// neither the approval which doesn't execute tx 3 nor the failed cancel of tx 4 removes them
+			executed(multisig.Methods.Approve, multisig.TxnIDParams{ID: 3}, exitcode.Ok, pending),
+			executed(multisig.Methods.Cancel, multisig.TxnIDParams{ID: 4}, exitcode.ErrForbidden, nil),
+		}, nil
+	}}
+	outcomes, err := multisigOutcomes(ctx, change)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]int{1: MultisigExecuted, 2: MultisigCancelled}, outcomes[msig])
+
+	// a cancel at threshold 2 is not mistaken for the execution
+	changes := &multisig.PendingTransactionChanges{
+		Removed: []multisig.TransactionChange{
+			{TxID: 1, Tx: multisig.Transaction{To: signer2, Approved: []address.Address{signer1}}},
+			{TxID: 2, Tx: multisig.Transaction{To: signer2, Approved: []address.Address{signer1}}},
+		},
+	}
+	events := diffMultisigEvents(msig, 2, []address.Address{signer1, signer2}, changes, outcomes[msig])
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, MultisigExecuted, events[0].Event)
+	assert.Equal(t, MultisigCancelled, events[1].Event)
+	assert.Equal(t, "CANCELLED", events[1].EventName)
+}
@@ -0,0 +1,150 @@
+package statechange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/power"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// ChainPower the network totals of the power actor, the same as the chain power of filmeta-model
+type ChainPower struct {
+	TotalRawBytesPower         abi.TokenAmount `json:"total_raw_bytes_power"`
+	TotalQABytesPower          abi.TokenAmount `json:"total_qa_bytes_power"`
+	TotalRawBytesCommitted     abi.TokenAmount `json:"total_raw_bytes_committed"`
+	TotalQABytesCommitted      abi.TokenAmount `json:"total_qa_bytes_committed"`
+	TotalPledgeCollateral      abi.TokenAmount `json:"total_pledge_collateral"`
+	QASmoothedPositionEstimate abi.TokenAmount `json:"qa_smoothed_position_estimate"`
+	QASmoothedVelocityEstimate abi.TokenAmount `json:"qa_smoothed_velocity_estimate"`
+	MinerCount                 uint64          `json:"miner_count"`
+	ParticipatingMinerCount    uint64          `json:"participating_miner_count"`
+}
+
+// PowerEvent a change of the power claim of the miner, like the actor claim of filmeta-model
+type PowerEvent struct {
+	MinerID         address.Address  `json:"miner_id"`
+	RawBytePower    abi.StoragePower `json:"raw_byte_power"`
+	QualityAdjPower abi.StoragePower `json:"quality_adj_power"`
+	// RawBytePowerDelta and QualityAdjPowerDelta are negative if the power drops
+	RawBytePowerDelta    abi.StoragePower `json:"raw_byte_power_delta"`
+	QualityAdjPowerDelta abi.StoragePower `json:"quality_adj_power_delta"`
+	// Network the network totals after the change
+	Network *ChainPower `json:"network"`
+}
+
+func NewPowerEvent(minerID address.Address, from, to power.Claim, network *ChainPower) *PowerEvent {
+	return &PowerEvent{
+		MinerID:              minerID,
+		RawBytePower:         to.RawBytePower,
+		QualityAdjPower:      to.QualityAdjPower,
+		RawBytePowerDelta:    big.Sub(to.RawBytePower, from.RawBytePower),
+		QualityAdjPowerDelta: big.Sub(to.QualityAdjPower, from.QualityAdjPower),
+		Network:              network,
+	}
+}
+
+func init() {
+	Register(model.PowerKind, ExtractorFunc(ExtractPowerEvents))
+}
+
+// ExtractPowerEvents extract the claim changes of the subscribed miners,
+// the network totals are sent to the subscribers of the power actor whenever its state changes
+func ExtractPowerEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	pre, post, err := change.Actors(power.Address)
+	if err != nil {
+		return nil, err
+	}
+	if pre == nil || post == nil || pre.Head.Equals(post.Head) {
+		return nil, nil
+	}
+	preState, err := power.Load(change.Store, pre)
+	if err != nil {
+		return nil, fmt.Errorf("load pre power state: %w", err)
+	}
+	curState, err := power.Load(change.Store, post)
+	if err != nil {
+		return nil, fmt.Errorf("load power state: %w", err)
+	}
+	network, err := NewChainPower(curState)
+	if err != nil {
+		return nil, fmt.Errorf("load network totals: %w", err)
+	}
+
+	subscribed := addressSet(addrs)
+	var events []*Event
+	if _, ok := subscribed[power.Address]; ok {
+		events = append(events, NewEvent(model.PowerKind, change, power.Address, network))
+	}
+	changed, err := preState.ClaimsChanged(curState)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return events, nil
+	}
+	claims, err := power.DiffClaims(ctx, change.Store, preState, curState)
+	if err != nil {
+		return nil, fmt.Errorf("diff claims: %w", err)
+	}
+	for _, powerEvent := range claimEvents(claims, network) {
+		if _, ok := subscribed[powerEvent.MinerID]; !ok {
+			continue
+		}
+		events = append(events, NewEvent(model.PowerKind, change, powerEvent.MinerID, powerEvent))
+	}
+	return events, nil
+}
+
+func NewChainPower(state power.State) (*ChainPower, error) {
+	totalPower, err := state.TotalPower()
+	if err != nil {
+		return nil, err
+	}
+	totalCommitted, err := state.TotalCommitted()
+	if err != nil {
+		return nil, err
+	}
+	totalLocked, err := state.TotalLocked()
+	if err != nil {
+		return nil, err
+	}
+	smoothed, err := state.TotalPowerSmoothed()
+	if err != nil {
+		return nil, err
+	}
+	participating, total, err := state.MinerCounts()
+	if err != nil {
+		return nil, err
+	}
+	return &ChainPower{
+		TotalRawBytesPower:         totalPower.RawBytePower,
+		TotalQABytesPower:          totalPower.QualityAdjPower,
+		TotalRawBytesCommitted:     totalCommitted.RawBytePower,
+		TotalQABytesCommitted:      totalCommitted.QualityAdjPower,
+		TotalPledgeCollateral:      totalLocked,
+		QASmoothedPositionEstimate: smoothed.PositionEstimate,
+		QASmoothedVelocityEstimate: smoothed.VelocityEstimate,
+		MinerCount:                 total,
+		ParticipatingMinerCount:    participating,
+	}, nil
+}
+
+// claimEvents a removed claim is considered a claim of zero power
+func claimEvents(claims *power.ClaimChanges, network *ChainPower) (events []*PowerEvent) {
+	zero := power.Claim{RawBytePower: big.Zero(), QualityAdjPower: big.Zero()}
+	for _, added := range claims.Added {
+		events = append(events, NewPowerEvent(added.Miner, zero, added.Claim, network))
+	}
+	for _, modified := range claims.Modified {
+		events = append(events, NewPowerEvent(modified.Miner, modified.From, modified.To, network))
+	}
+	for _, removed := range claims.Removed {
+		events = append(events, NewPowerEvent(removed.Miner, removed.Claim, zero, network))
+	}
+	return
+}
@@ -0,0 +1,36 @@
+package statechange
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/power"
+)
+
+func TestClaimEvents(t *testing.T) {
+	miner, _ := address.NewIDAddress(1000) //nolint:errcheck
+	claim := func(raw, qa int64) power.Claim {
+		return power.Claim{RawBytePower: big.NewInt(raw), QualityAdjPower: big.NewInt(qa)}
+	}
+	network := &ChainPower{MinerCount: 10}
+	claims := &power.ClaimChanges{
+		Added:    []power.ClaimInfo{{Miner: miner, Claim: claim(0, 0)}},
+		Modified: []power.ClaimModification{{Miner: miner, From: claim(100, 1000), To: claim(60, 600)}},
+		Removed:  []power.ClaimInfo{{Miner: miner, Claim: claim(60, 600)}},
+	}
+	events := claimEvents(claims, network)
+	assert.Equal(t, 3, len(events))
+
+	type want struct{ raw, qa, rawDelta, qaDelta int64 }
+	for i, w := range []want{{0, 0, 0, 0}, {60, 600, -40, -400}, {0, 0, -60, -600}} {
+		assert.Equal(t, miner, events[i].MinerID)
+		assert.Equal(t, big.NewInt(w.raw), events[i].RawBytePower)
+		assert.Equal(t, big.NewInt(w.qa), events[i].QualityAdjPower)
+		assert.Equal(t, big.NewInt(w.rawDelta), events[i].RawBytePowerDelta)
+		assert.Equal(t, big.NewInt(w.qaDelta), events[i].QualityAdjPowerDelta)
+		assert.Equal(t, network, events[i].Network)
+	}
+}
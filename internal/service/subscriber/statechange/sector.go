@@ -0,0 +1,248 @@
+package statechange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	minermodel "github.com/BitRainforest/filmeta-model/actors/miner"
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/bitrainforest/pulsar/chain/actors/adt"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/miner"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+var sectorEventNames = map[int]string{
+	minermodel.PreCommitAdded:      "PRECOMMIT_ADDED",
+	minermodel.PreCommitExpired:    "PRECOMMIT_EXPIRED",
+	minermodel.CommitCapacityAdded: "COMMIT_CAPACITY_ADDED",
+	minermodel.SectorAdded:         "SECTOR_ADDED",
+	minermodel.SectorExtended:      "SECTOR_EXTENDED",
+	minermodel.SectorFaulted:       "SECTOR_FAULTED",
+	minermodel.SectorRecovering:    "SECTOR_RECOVERING",
+	minermodel.SectorRecovered:     "SECTOR_RECOVERED",
+	minermodel.SectorExpired:       "SECTOR_EXPIRED",
+	minermodel.SectorTerminated:    "SECTOR_TERMINATED",
+}
+
+// SectorEvent the miner sector event of filmeta-model with the name of the event
+type SectorEvent struct {
+	minermodel.SectorEvent
+	// EventName the name of Event, e.g. SECTOR_FAULTED
+	EventName string `json:"event_name"`
+}
+
+func NewSectorEvent(minerID address.Address, sectorNumber abi.SectorNumber, event int) *SectorEvent {
+	return &SectorEvent{
+		SectorEvent: minermodel.SectorEvent{
+			MinerID:      minerID,
+			SectorNumber: sectorNumber,
+			Event:        event,
+		},
+		EventName: sectorEventNames[event],
+	}
+}
+
+func init() {
+	Register(model.SectorKind, ExtractorFunc(ExtractSectorEvents))
+}
+
+// ExtractSectorEvents extract the sector lifecycle events of the miners,
+// the addresses which are not miners are ignored
+func ExtractSectorEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	var events []*Event
+	for _, addr := range addrs {
+		pre, post, err := change.Actors(addr)
+		if err != nil {
+			return nil, err
+		}
+		// a miner created by the change has no sectors yet
+		if pre == nil || post == nil || !builtin.IsStorageMinerActor(post.Code) {
+			continue
+		}
+		if pre.Head.Equals(post.Head) {
+			continue
+		}
+		preState, err := miner.Load(change.Store, pre)
+		if err != nil {
+			return nil, fmt.Errorf("load pre miner state %v: %w", addr.String(), err)
+		}
+		curState, err := miner.Load(change.Store, post)
+		if err != nil {
+			return nil, fmt.Errorf("load miner state %v: %w", addr.String(), err)
+		}
+		sectorEvents, err := DiffSectorEvents(ctx, change.Store, change.Height(), addr, preState, curState)
+		if err != nil {
+			// the other miners are still extracted, just to log
+			log.Errorf("[ExtractSectorEvents] diff miner %v at %d err:%v", addr.String(), change.Height(), err)
+			continue
+		}
+		for _, sectorEvent := range sectorEvents {
+			sectorEvent.Height, sectorEvent.StateRoot = change.Height(), change.StateRoot()
+			events = append(events, NewEvent(model.SectorKind, change, addr, sectorEvent))
+		}
+	}
+	return events, nil
+}
+
+// DiffSectorEvents get the sector lifecycle events of the miner from pre to cur
+func DiffSectorEvents(ctx context.Context, store adt.Store, height abi.ChainEpoch,
+	minerID address.Address, pre, cur miner.State) ([]*SectorEvent, error) {
+	preCommits, err := miner.DiffPreCommits(ctx, store, pre, cur)
+	if err != nil {
+		return nil, fmt.Errorf("diff precommits: %w", err)
+	}
+	sectors, err := miner.DiffSectors(ctx, store, pre, cur)
+	if err != nil {
+		return nil, fmt.Errorf("diff sectors: %w", err)
+	}
+	deadlines, err := miner.DiffDeadlines(pre, cur)
+	if err != nil {
+		return nil, fmt.Errorf("diff deadlines: %w", err)
+	}
+	// the sectors removed from the partitions are still in the sectors of the pre state
+	removed, err := loadRemovedSectors(pre, deadlines)
+	if err != nil {
+		return nil, fmt.Errorf("load removed sectors: %w", err)
+	}
+
+	events := preCommitEvents(minerID, preCommits, sectors)
+	events = append(events, sectorChangeEvents(minerID, sectors)...)
+	partEvents, err := partitionEvents(minerID, height, deadlines, removed)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, partEvents...), nil
+}
+
+// preCommitEvents the removed precommits which are not proven are expired
+func preCommitEvents(minerID address.Address, preCommits *miner.PreCommitChanges,
+	sectors *miner.SectorChanges) (events []*SectorEvent) {
+	proven := make(map[abi.SectorNumber]struct{}, len(sectors.Added))
+	for _, sector := range sectors.Added {
+		proven[sector.SectorNumber] = struct{}{}
+	}
+	for _, added := range preCommits.Added {
+		events = append(events, NewSectorEvent(minerID, added.Info.SectorNumber, minermodel.PreCommitAdded))
+	}
+	for _, removed := range preCommits.Removed {
+		if _, ok := proven[removed.Info.SectorNumber]; ok {
+			continue
+		}
+		events = append(events, NewSectorEvent(minerID, removed.Info.SectorNumber, minermodel.PreCommitExpired))
+	}
+	return
+}
+
+// sectorChangeEvents the added sectors without deals are committed capacity
+func sectorChangeEvents(minerID address.Address, sectors *miner.SectorChanges) (events []*SectorEvent) {
+	for _, added := range sectors.Added {
+		event := minermodel.SectorAdded
+		if len(added.DealIDs) == 0 {
+			event = minermodel.CommitCapacityAdded
+		}
+		events = append(events, NewSectorEvent(minerID, added.SectorNumber, event))
+	}
+	for _, extended := range sectors.Extended {
+		events = append(events, NewSectorEvent(minerID, extended.To.SectorNumber, minermodel.SectorExtended))
+	}
+	return
+}
+
+func loadRemovedSectors(pre miner.State, deadlines miner.DeadlinesDiff) (map[abi.SectorNumber]*miner.SectorOnChainInfo, error) {
+	var bfs []bitfield.BitField
+	for _, dl := range deadlines {
+		for _, part := range dl {
+			bfs = append(bfs, part.Removed)
+		}
+	}
+	removed := make(map[abi.SectorNumber]*miner.SectorOnChainInfo)
+	if len(bfs) == 0 {
+		return removed, nil
+	}
+	merged, err := bitfield.MultiMerge(bfs...)
+	if err != nil {
+		return nil, err
+	}
+	if count, err := merged.Count(); err != nil || count == 0 {
+		return removed, err
+	}
+	// LoadSectors loads all sectors if the bitfield is nil, merged is never nil here
+	infos, err := pre.LoadSectors(&merged)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		removed[info.SectorNumber] = info
+	}
+	return removed, nil
+}
+
+// partitionEvents the removed sectors are expired if they reached the expiration, or terminated early
+func partitionEvents(minerID address.Address, height abi.ChainEpoch, deadlines miner.DeadlinesDiff,
+	removed map[abi.SectorNumber]*miner.SectorOnChainInfo) (events []*SectorEvent, err error) {
+	appendEvents := func(bf bitfield.BitField, eventFn func(abi.SectorNumber) int) error {
+		return bf.ForEach(func(sno uint64) error {
+			events = append(events, NewSectorEvent(minerID, abi.SectorNumber(sno), eventFn(abi.SectorNumber(sno))))
+			return nil
+		})
+	}
+	constEvent := func(event int) func(abi.SectorNumber) int {
+		return func(abi.SectorNumber) int { return event }
+	}
+	removedEvent := func(sno abi.SectorNumber) int {
+		if info, ok := removed[sno]; ok && info.Expiration <= height {
+			return minermodel.SectorExpired
+		}
+		return minermodel.SectorTerminated
+	}
+
+	err = forEachPartitionDiff(deadlines, func(_, _ uint64, part *miner.PartitionDiff) error {
+		if err := appendEvents(part.Removed, removedEvent); err != nil {
+			return err
+		}
+		if err := appendEvents(part.Faulted, constEvent(minermodel.SectorFaulted)); err != nil {
+			return err
+		}
+		if err := appendEvents(part.Recovering, constEvent(minermodel.SectorRecovering)); err != nil {
+			return err
+		}
+		return appendEvents(part.Recovered, constEvent(minermodel.SectorRecovered))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// forEachPartitionDiff walk the partitions of the deadlines in order so the events are stable
+func forEachPartitionDiff(deadlines miner.DeadlinesDiff, cb func(dlIdx, partIdx uint64, part *miner.PartitionDiff) error) error {
+	dlIdxs := make([]uint64, 0, len(deadlines))
+	for dlIdx := range deadlines {
+		dlIdxs = append(dlIdxs, dlIdx)
+	}
+	sortUint64s(dlIdxs)
+	for _, dlIdx := range dlIdxs {
+		dl := deadlines[dlIdx]
+		partIdxs := make([]uint64, 0, len(dl))
+		for partIdx := range dl {
+			partIdxs = append(partIdxs, partIdx)
+		}
+		sortUint64s(partIdxs)
+		for _, partIdx := range partIdxs {
+			if err := cb(dlIdx, partIdx, dl[partIdx]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortUint64s(s []uint64) {
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+}
@@ -0,0 +1,100 @@
+package statechange
+
+import (
+	"testing"
+
+	minermodel "github.com/BitRainforest/filmeta-model/actors/miner"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/miner"
+)
+
+var testMiner, _ = address.NewIDAddress(1000) //nolint:errcheck
+
+func eventsOf(events []*SectorEvent) (list [][2]int) {
+	for _, e := range events {
+		list = append(list, [2]int{int(e.SectorNumber), e.Event})
+	}
+	return
+}
+
+func preCommit(sno abi.SectorNumber) miner.SectorPreCommitOnChainInfo {
+	return miner.SectorPreCommitOnChainInfo{Info: miner.SectorPreCommitInfo{SectorNumber: sno}}
+}
+
+func TestPreCommitEvents(t *testing.T) {
+	preCommits := &miner.PreCommitChanges{
+		Added:   []miner.SectorPreCommitOnChainInfo{preCommit(3)},
+		Removed: []miner.SectorPreCommitOnChainInfo{preCommit(1), preCommit(2)},
+	}
+	// sector 1 is proven, sector 2 is expired
+	sectors := &miner.SectorChanges{
+		Added: []miner.SectorOnChainInfo{{SectorNumber: 1}},
+	}
+	events := preCommitEvents(testMiner, preCommits, sectors)
+	assert.Equal(t, [][2]int{{3, minermodel.PreCommitAdded}, {2, minermodel.PreCommitExpired}}, eventsOf(events))
+	for _, e := range events {
+		assert.Equal(t, testMiner, e.MinerID)
+		assert.Equal(t, sectorEventNames[e.Event], e.EventName)
+	}
+}
+
+func TestSectorChangeEvents(t *testing.T) {
+	sectors := &miner.SectorChanges{
+		Added: []miner.SectorOnChainInfo{
+			{SectorNumber: 1},
+			{SectorNumber: 2, DealIDs: []abi.DealID{10}},
+		},
+		Extended: []miner.SectorExtensions{
+			{From: miner.SectorOnChainInfo{SectorNumber: 3, Expiration: 100},
+				To: miner.SectorOnChainInfo{SectorNumber: 3, Expiration: 200}},
+		},
+	}
+	events := sectorChangeEvents(testMiner, sectors)
+	assert.Equal(t, [][2]int{
+		{1, minermodel.CommitCapacityAdded}, {2, minermodel.SectorAdded}, {3, minermodel.SectorExtended},
+	}, eventsOf(events))
+}
+
+func TestPartitionEvents(t *testing.T) {
+	deadlines := miner.DeadlinesDiff{
+		5: miner.DeadlineDiff{
+			1: &miner.PartitionDiff{
+				Removed:    bitfield.New(),
+				Recovered:  bitfield.NewFromSet([]uint64{20}),
+				Faulted:    bitfield.New(),
+				Recovering: bitfield.New(),
+			},
+		},
+		2: miner.DeadlineDiff{
+			0: &miner.PartitionDiff{
+				Removed:    bitfield.NewFromSet([]uint64{7, 8}),
+				Recovered:  bitfield.New(),
+				Faulted:    bitfield.NewFromSet([]uint64{9}),
+				Recovering: bitfield.NewFromSet([]uint64{11}),
+			},
+		},
+	}
+	// sector 7 reached its expiration, sector 8 is terminated early
+	removed := map[abi.SectorNumber]*miner.SectorOnChainInfo{
+		7: {SectorNumber: 7, Expiration: 100},
+		8: {SectorNumber: 8, Expiration: 500},
+	}
+	events, err := partitionEvents(testMiner, 100, deadlines, removed)
+	assert.Nil(t, err)
+	assert.Equal(t, [][2]int{
+		{7, minermodel.SectorExpired}, {8, minermodel.SectorTerminated}, {9, minermodel.SectorFaulted},
+		{11, minermodel.SectorRecovering}, {20, minermodel.SectorRecovered},
+	}, eventsOf(events))
+}
+
+func TestKinds(t *testing.T) {
+	for _, kind := range []string{"sector", "deal", "multisig", "verifreg", "power", "actor_created", "deadline", "deadline_reminder", "balance"} {
+		_, ok := Lookup(kind)
+		assert.True(t, ok)
+		assert.Contains(t, Kinds(), kind)
+	}
+}
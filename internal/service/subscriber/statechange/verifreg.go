@@ -0,0 +1,129 @@
+package statechange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/verifreg"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+// the verified registry events
+const (
+	_ = iota
+	VerifierAdded
+	VerifierRemoved
+	VerifierAllowanceChanged
+	DataCapGranted
+	DataCapConsumed
+)
+
+var verifregEventNames = map[int]string{
+	VerifierAdded:            "VERIFIER_ADDED",
+	VerifierRemoved:          "VERIFIER_REMOVED",
+	VerifierAllowanceChanged: "VERIFIER_ALLOWANCE_CHANGED",
+	DataCapGranted:           "DATACAP_GRANTED",
+	DataCapConsumed:          "DATACAP_CONSUMED",
+}
+
+// VerifregEvent a change of the allowance of a verifier or the DataCap of a verified client
+type VerifregEvent struct {
+	// Address the verifier or the verified client
+	Address   address.Address `json:"address"`
+	Event     int             `json:"event"`
+	EventName string          `json:"event_name"`
+	// DataCap the allowance or the DataCap after the change, it's zero if removed
+	DataCap abi.StoragePower `json:"data_cap"`
+	// Delta DataCap minus the one before the change
+	Delta abi.StoragePower `json:"delta"`
+}
+
+func NewVerifregEvent(addr address.Address, event int, before, after abi.StoragePower) *VerifregEvent {
+	return &VerifregEvent{
+		Address:   addr,
+		Event:     event,
+		EventName: verifregEventNames[event],
+		DataCap:   after,
+		Delta:     big.Sub(after, before),
+	}
+}
+
+func init() {
+	Register(model.VerifregKind, ExtractorFunc(ExtractVerifregEvents))
+}
+
+// ExtractVerifregEvents extract the events of the subscribed verifiers and verified clients
+func ExtractVerifregEvents(ctx context.Context, change *Change, addrs []address.Address) ([]*Event, error) {
+	pre, post, err := change.Actors(verifreg.Address)
+	if err != nil {
+		return nil, err
+	}
+	if pre == nil || post == nil || pre.Head.Equals(post.Head) {
+		return nil, nil
+	}
+	preState, err := verifreg.Load(change.Store, pre)
+	if err != nil {
+		return nil, fmt.Errorf("load pre verifreg state: %w", err)
+	}
+	curState, err := verifreg.Load(change.Store, post)
+	if err != nil {
+		return nil, fmt.Errorf("load verifreg state: %w", err)
+	}
+	verifiers, err := verifreg.DiffVerifiers(ctx, change.Store, preState, curState)
+	if err != nil {
+		return nil, fmt.Errorf("diff verifiers: %w", err)
+	}
+	clients, err := verifreg.DiffVerifiedClients(ctx, change.Store, preState, curState)
+	if err != nil {
+		return nil, fmt.Errorf("diff verified clients: %w", err)
+	}
+
+	subscribed := addressSet(addrs)
+	var events []*Event
+	for _, verifregEvent := range append(verifierEvents(verifiers), clientEvents(clients)...) {
+		if _, ok := subscribed[verifregEvent.Address]; !ok {
+			continue
+		}
+		event := NewEvent(model.VerifregKind, change, verifreg.Address, verifregEvent)
+		event.Routes = []address.Address{verifregEvent.Address}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func verifierEvents(changes *verifreg.VerifierChanges) (events []*VerifregEvent) {
+	for _, added := range changes.Added {
+		events = append(events, NewVerifregEvent(added.Address, VerifierAdded, big.Zero(), added.DataCap))
+	}
+	for _, modified := range changes.Modified {
+		events = append(events, NewVerifregEvent(modified.After.Address, VerifierAllowanceChanged,
+			modified.Before.DataCap, modified.After.DataCap))
+	}
+	for _, removed := range changes.Removed {
+		events = append(events, NewVerifregEvent(removed.Address, VerifierRemoved, removed.DataCap, big.Zero()))
+	}
+	return
+}
+
+// clientEvents the verified client is removed once the DataCap is used up
+func clientEvents(changes *verifreg.VerifierChanges) (events []*VerifregEvent) {
+	for _, added := range changes.Added {
+		events = append(events, NewVerifregEvent(added.Address, DataCapGranted, big.Zero(), added.DataCap))
+	}
+	for _, modified := range changes.Modified {
+		event := DataCapGranted
+		if modified.After.DataCap.LessThan(modified.Before.DataCap) {
+			event = DataCapConsumed
+		}
+		events = append(events, NewVerifregEvent(modified.After.Address, event,
+			modified.Before.DataCap, modified.After.DataCap))
+	}
+	for _, removed := range changes.Removed {
+		events = append(events, NewVerifregEvent(removed.Address, DataCapConsumed, removed.DataCap, big.Zero()))
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package statechange
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/chain/actors/builtin/verifreg"
+)
+
+func TestVerifregEvents(t *testing.T) {
+	addr, _ := address.NewIDAddress(100) //nolint:errcheck
+	info := func(dataCap int64) verifreg.VerifierInfo {
+		return verifreg.VerifierInfo{Address: addr, DataCap: big.NewInt(dataCap)}
+	}
+	changes := &verifreg.VerifierChanges{
+		Added: []verifreg.VerifierInfo{info(100)},
+		Modified: []verifreg.VerifierChange{
+			{Before: info(100), After: info(40)},
+			{Before: info(40), After: info(90)},
+		},
+		Removed: []verifreg.VerifierInfo{info(30)},
+	}
+
+	type want struct {
+		event          int
+		dataCap, delta int64
+	}
+	check := func(events []*VerifregEvent, wants []want) {
+		assert.Equal(t, len(wants), len(events))
+		for i, w := range wants {
+			assert.Equal(t, addr, events[i].Address)
+			assert.Equal(t, w.event, events[i].Event)
+			assert.Equal(t, verifregEventNames[w.event], events[i].EventName)
+			assert.Equal(t, big.NewInt(w.dataCap), events[i].DataCap)
+			assert.Equal(t, big.NewInt(w.delta), events[i].Delta)
+		}
+	}
+	check(verifierEvents(changes), []want{
+		{VerifierAdded, 100, 100},
+		{VerifierAllowanceChanged, 40, -60},
+		{VerifierAllowanceChanged, 90, 50},
+		{VerifierRemoved, 0, -30},
+	})
+	check(clientEvents(changes), []want{
+		{DataCapGranted, 100, 100},
+		{DataCapConsumed, 40, -60},
+		{DataCapGranted, 90, 50},
+		{DataCapConsumed, 0, -30},
+	})
+}
@@ -0,0 +1,103 @@
+package subscriber
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/core/log"
+	"github.com/bitrainforest/filmeta-hic/core/threading"
+	"github.com/bitrainforest/filmeta-hic/model"
+
+	"github.com/bitrainforest/pulsar/internal/cache"
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+)
+
+var (
+	_ Notify   = (*streamNotify)(nil)
+	_ redriver = (*streamNotify)(nil)
+)
+
+// StreamActiveRefresh how long an app with a streaming client is believed to still have one,
+// the apps without a client are checked every time, so that a new client misses no notification
+const StreamActiveRefresh = 10 * time.Second
+
+type (
+	// streamNotify append the notifications to the streams of the apps, the streaming clients
+	// of the apps read them on any instance and resume from a cursor.
+	// the apps without a streaming client lately are skipped
+	streamNotify struct {
+		stream cache.NotifyStream
+		opts   NotifyOpts
+		// active appId -> the time when the app is found to have a streaming client
+		active sync.Map
+		now    func() time.Time
+	}
+)
+
+func NewStreamNotify(stream cache.NotifyStream, optFns ...NotifyOptFn) Notify {
+	return &streamNotify{stream: stream, opts: newNotifyOpts(optFns...), now: time.Now}
+}
+
+// isActive the notifications are appended anyway if it's unknown, a client may be waiting for them.
+// Only the active apps are cached, the client may connect to another instance at any time.
+func (n *streamNotify) isActive(appId string) bool {
+	now := n.now()
+	if val, ok := n.active.Load(appId); ok && now.Sub(val.(time.Time)) < StreamActiveRefresh {
+		return true
+	}
+	active, err := n.stream.Active(context.Background(), appId)
+	if err != nil {
+		log.Errorf("[streamNotify] check appId:%v err: %s", appId, err)
+		return true
+	}
+	if active {
+		n.active.Store(appId, now)
+	} else {
+		n.active.Delete(appId)
+	}
+	return active
+}
+
+func (n *streamNotify) Notify(appIds []string, msg model.NotifyMessage) error {
+	msgByte, err := msg.Get()
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, appId := range appIds {
+		if !n.isActive(appId) {
+			continue
+		}
+		wg.Add(1)
+		to := appId
+		threading.GoSafe(func() {
+			defer wg.Done()
+			attempts, err := n.append(to, msgByte)
+			if err != nil {
+				log.Errorf("[streamNotify] append appId:%v attempts:%d err: %s", to, attempts, err)
+				n.opts.deadLetter(model2.StreamChannel, to, "", msgByte, attempts, err)
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+func (n *streamNotify) append(appId string, msgByte []byte) (int, error) {
+	return n.opts.retry.Do(func() error {
+		_, err := n.stream.Append(context.Background(), appId, msgByte)
+		return err
+	})
+}
+
+func (n *streamNotify) Redrive(letter *model2.DeadLetter) error {
+	if letter.Channel != model2.StreamChannel {
+		return errOtherChannel
+	}
+	_, err := n.append(letter.AppId, []byte(letter.Payload))
+	return err
+}
+
+func (n *streamNotify) Close() {
+}
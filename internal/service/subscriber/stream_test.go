@@ -0,0 +1,102 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrainforest/pulsar/internal/cache"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+var _ cache.NotifyStream = (*MockNotifyStream)(nil)
+
+type MockNotifyStream struct {
+	lock    sync.Mutex
+	streams map[string][]cache.StreamEvent
+	// down the appends of the app fail
+	down string
+	// idle the app has no streaming client
+	idle string
+}
+
+func (m *MockNotifyStream) Append(ctx context.Context, appId string, payload []byte) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if appId == m.down {
+		return "", errors.New("stream is down")
+	}
+	cursor := strconv.Itoa(len(m.streams[appId])+1) + "-0"
+	m.streams[appId] = append(m.streams[appId], cache.StreamEvent{Cursor: cursor, Payload: payload})
+	return cursor, nil
+}
+
+func (m *MockNotifyStream) Read(ctx context.Context, appId, cursor string, count int64,
+	block time.Duration) ([]cache.StreamEvent, error) {
+	return nil, nil
+}
+
+func (m *MockNotifyStream) LastCursor(ctx context.Context, appId string) (string, error) {
+	return cache.StreamBeginning, nil
+}
+
+func (m *MockNotifyStream) FirstCursor(ctx context.Context, appId string) (string, error) {
+	return "", nil
+}
+
+func (m *MockNotifyStream) Touch(ctx context.Context, appId string) error {
+	return nil
+}
+
+func (m *MockNotifyStream) Active(ctx context.Context, appId string) (bool, error) {
+	return appId != m.idle, nil
+}
+
+func TestStreamNotify(t *testing.T) {
+	stream := &MockNotifyStream{streams: make(map[string][]cache.StreamEvent), down: "app3", idle: "app4"}
+	deadLetter := &MockDeadLetterDao{}
+	notify := NewStreamNotify(stream,
+		WithRetryPolicy(RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+		WithDeadLetterDao(deadLetter))
+
+	assert.Nil(t, notify.Notify([]string{"app1", "app2", "app3", "app4"}, rawMessage(`{"Height":100}`)))
+	assert.Nil(t, notify.Notify([]string{"app1"}, rawMessage(`{"Height":101}`)))
+
+	if assert.Len(t, stream.streams["app1"], 2) {
+		assert.Equal(t, "2-0", stream.streams["app1"][1].Cursor)
+		assert.JSONEq(t, `{"Height":101}`, string(stream.streams["app1"][1].Payload))
+	}
+	assert.Len(t, stream.streams["app2"], 1)
+	// the app without a streaming client is skipped
+	assert.Empty(t, stream.streams["app4"])
+	if assert.Len(t, deadLetter.letters, 1) {
+		letter := deadLetter.letters[0]
+		assert.Equal(t, "app3", letter.AppId)
+		assert.Equal(t, model.StreamChannel, letter.Channel)
+		assert.Equal(t, 2, letter.Attempts)
+
+		// re-driven after the stream recovers
+		stream.down = ""
+		assert.Nil(t, notify.(redriver).Redrive(letter))
+		assert.Len(t, stream.streams["app3"], 1)
+	}
+}
+
+func TestStreamNotify_ClientConnected(t *testing.T) {
+	stream := &MockNotifyStream{streams: make(map[string][]cache.StreamEvent), idle: "app1"}
+	notify := NewStreamNotify(stream)
+
+	assert.Nil(t, notify.Notify([]string{"app1"}, rawMessage(`{"Height":100}`)))
+	assert.Empty(t, stream.streams["app1"])
+	// the client connects right after, the next notification is not skipped
+	stream.idle = ""
+	assert.Nil(t, notify.Notify([]string{"app1"}, rawMessage(`{"Height":101}`)))
+	if assert.Len(t, stream.streams["app1"], 1) {
+		assert.JSONEq(t, `{"Height":101}`, string(stream.streams["app1"][0].Payload))
+	}
+}
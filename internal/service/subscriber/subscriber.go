@@ -7,11 +7,20 @@ import (
 	"github.com/bitrainforest/filmeta-hic/core/threading"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/lotus/chain/types"
 
 	"github.com/bitrainforest/filmeta-hic/core/fx"
 
+	"github.com/bitrainforest/pulsar/chain/actors/builtin"
+	builtininit "github.com/bitrainforest/pulsar/chain/actors/builtin/init"
+	"github.com/bitrainforest/pulsar/internal/cache"
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+	"github.com/bitrainforest/pulsar/internal/service/subscriber/filterexpr"
+	"github.com/bitrainforest/pulsar/internal/service/subscriber/statechange"
 	"github.com/bitrainforest/pulsar/internal/utils/locker"
 	"github.com/pkg/errors"
 
@@ -33,9 +42,29 @@ type (
 	Subscriber struct {
 		opts         *Opts
 		subAllAppIds sync.Map
-		workPool     *ants.Pool
-		wg           sync.WaitGroup
-		notify       Notify
+		// subActorAppIds actor family -> *sync.Map of the appIds who subscribe the actor family
+		subActorAppIds sync.Map
+		// subHeadAppIds the appIds who subscribe the heads
+		subHeadAppIds sync.Map
+		// exprs the compiled filter expressions, expression -> *filterexpr.Expr
+		exprs    *lru.Cache
+		workPool *ants.Pool
+		wg       sync.WaitGroup
+		notify   Notify
+		// appConfirmations appId -> how many epochs deep the tipset must be before the app is notified
+		appConfirmations sync.Map
+		unconfirmed      *confirmBuffer
+		// works the deliveries of each tipset in progress, the reverts wait for them
+		works *tipSetWork
+		// kindSubs the cached subs of the state change kinds, they're reloaded when the version changes
+		kindSubs kindSubsCache
+	}
+
+	kindSubsCache struct {
+		lock     sync.Mutex
+		loaded   bool
+		version  int64
+		subKinds map[string]map[string][]string
 	}
 )
 
@@ -59,9 +88,12 @@ func NewSub(initAppIds []string, notify Notify, optFns ...OptFn) (*Subscriber, e
 	}
 
 	Sub = &Subscriber{
-		subAllAppIds: sync.Map{},
-		opts:         &opts,
-		wg:           sync.WaitGroup{},
+		subAllAppIds:   sync.Map{},
+		subActorAppIds: sync.Map{},
+		opts:           &opts,
+		wg:             sync.WaitGroup{},
+		unconfirmed:    newConfirmBuffer(opts.unconfirmedStore),
+		works:          newTipSetWork(),
 	}
 	var (
 		err error
@@ -69,6 +101,9 @@ func NewSub(initAppIds []string, notify Notify, optFns ...OptFn) (*Subscriber, e
 	if Sub.workPool, err = ants.NewPool(int(Sub.opts.workPoolNum)); err != nil {
 		return nil, err
 	}
+	if Sub.exprs, err = lru.New(MaxCachedExprs); err != nil {
+		return nil, err
+	}
 
 	for _, appId := range initAppIds {
 		Sub.AppendAppId(appId)
@@ -78,13 +113,72 @@ func NewSub(initAppIds []string, notify Notify, optFns ...OptFn) (*Subscriber, e
 }
 
 func (sub *Subscriber) AppendAppId(appId string) {
-	sub.subAllAppIds.LoadOrStore(appId, true)
+	sub.subAllAppIds.LoadOrStore(appId, &model2.SubAllFilter{})
+}
+
+// AppendSubAll add the app who subscribes all addresses or all addresses of an actor family,
+// the filter of the app is replaced if existed
+func (sub *Subscriber) AppendSubAll(subAll model2.UserAppSubAll) {
+	filter := subAll.SubAllFilter
+	switch model2.AllType(subAll.AllType) {
+	case model2.ActorAllType:
+		apps, _ := sub.subActorAppIds.LoadOrStore(subAll.ActorFamily, &sync.Map{})
+		apps.(*sync.Map).Store(subAll.AppId, &filter)
+	case model2.HeadAllType:
+		sub.subHeadAppIds.Store(subAll.AppId, struct{}{})
+	default:
+		sub.subAllAppIds.Store(subAll.AppId, &filter)
+	}
+}
+
+// CompileExpr compile the filter expression and cache it, the cached one is returned if existed
+func (sub *Subscriber) CompileExpr(expr string) (*filterexpr.Expr, error) {
+	if compiled, ok := sub.exprs.Get(expr); ok {
+		return compiled.(*filterexpr.Expr), nil
+	}
+	compiled, err := filterexpr.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	sub.exprs.Add(expr, compiled)
+	return compiled, nil
+}
+
+// matchExpr evaluate the filter expression, an empty expression matches every message
+func (sub *Subscriber) matchExpr(expr string, env *MsgEnv) bool {
+	if expr == "" {
+		return true
+	}
+	compiled, err := sub.CompileExpr(expr)
+	if err != nil {
+		log.Errorf("[matchExpr] compile %q err:%v", expr, err)
+		return false
+	}
+	matched, err := compiled.Match(env)
+	if err != nil {
+		log.Errorf("[matchExpr] match %q err:%v", expr, err)
+		return false
+	}
+	return matched
 }
 
 func (sub *Subscriber) RemoveAppId(appId string) {
 	sub.subAllAppIds.Delete(appId)
 }
 
+func (sub *Subscriber) RemoveSubAll(appId string, allType model2.AllType, actorFamily string) {
+	switch allType {
+	case model2.ActorAllType:
+		if apps, ok := sub.subActorAppIds.Load(actorFamily); ok {
+			apps.(*sync.Map).Delete(appId)
+		}
+	case model2.HeadAllType:
+		sub.subHeadAppIds.Delete(appId)
+	default:
+		sub.RemoveAppId(appId)
+	}
+}
+
 func (sub *Subscriber) GetActorAddress(ctx context.Context, from, to address.Address,
 	tip *types.TipSet) (address.Address, address.Address) {
 	var (
@@ -120,32 +214,21 @@ func (sub *Subscriber) GetActorAddress(ctx context.Context, from, to address.Add
 }
 
 func (sub *Subscriber) Notify(ctx context.Context, msg *model.Message) error {
-	sub.wg.Add(1)
-
-	lockerCli := locker.NewRedisLock(ctx, msg.MCid.String(), sub.opts.lockerExpire)
-	ok, err := lockerCli.Acquire(ctx)
-	if err != nil {
-		sub.wg.Done()
-		return fmt.Errorf("[MessageApplied] Notify Mcid: %s failed: %v", msg.MCid.String(), err)
-	}
-	if !ok {
-		sub.wg.Done()
-		log.Infof("[MessageApplied] locked message %s", msg.MCid.String())
-		return nil
-	}
-	// if lockerExpire=0, that means that when the message processing is complete,
-	// the msg key is  deleted
-	if sub.opts.lockerExpire == 0 {
-		defer lockerCli.Release(ctx)
-	}
-
-	return sub.workPool.Submit(func() {
-		defer sub.wg.Done()
+	return sub.withDeliveryLock(ctx, msg.MCid.String(), msg.TipSet, func() {
 		// step1 notify subscribers who have  sub to  all appIds
 		if !msg.IsImplicit() {
-			err := sub.notify.Notify(sub.GetAppIdsSubAll(), msg)
-			if err != nil {
-				log.Errorf("[MessageApplied] Notify %s failed: %v", msg.MCid.String(), err)
+			traceMsg := TraceMsg{Message: *msg.Msg}
+			if msg.Ret != nil {
+				traceMsg.Receipt = msg.Ret.MessageReceipt
+			}
+			env := NewMsgEnv(ctx, sub, traceMsg, msg.TipSet, msg.IsImplicit(), false)
+			if appIds := sub.GetAppIdsSubAll(env); len(appIds) > 0 {
+				payload := MessagePayload{Message: msg}
+				payload.DecodedCall, payload.ActorMethod = DecodeByEnv(env)
+				record := messageDelivery(msg, msg.Msg.Cid(), false)
+				if _, err := sub.deliver(ctx, msg.TipSet, appIds, &payload, record); err != nil {
+					log.Errorf("[MessageApplied] Notify %s failed: %v", msg.MCid.String(), err)
+				}
 			}
 		}
 
@@ -158,62 +241,460 @@ func (sub *Subscriber) Notify(ctx context.Context, msg *model.Message) error {
 				source <- item
 			}
 		}).Walk(func(item interface{}, pipe chan<- interface{}) {
-			typeMessage, ok := item.(types.Message)
+			traceMsg, ok := item.(TraceMsg)
 			if !ok {
 				return
 			}
-			// get actor address
-			from, to := sub.GetActorAddress(ctx, typeMessage.From, typeMessage.To, msg.TipSet)
-			appIds := sub.GetAppIdsByAddress()(context.Background(),
-				from.String(), to.String())
-			if len(appIds) == 0 {
-				return
-			}
 			isSubCall := true
-			if msg.Msg.Cid().Equals(typeMessage.Cid()) {
+			if msg.Msg.Cid().Equals(traceMsg.Cid()) {
 				isSubCall = false
+				// the receipt of the ApplyRet is the final outcome of the top-level message
+				traceMsg.Receipt = msg.Ret.MessageReceipt
+			}
+			env := NewMsgEnv(ctx, sub, traceMsg, msg.TipSet, msg.IsImplicit(), isSubCall)
+			appIds := sub.GetAppIdsByAddress()(context.Background(), env)
+			appIds = mergeAppIds(appIds, sub.GetAppIdsByActor(env))
+			if len(appIds) == 0 {
+				return
 			}
 
 			oneMsg := model.OneMessage{
-				Msg:       typeMessage,
+				Msg:       traceMsg.Message,
 				Implicit:  msg.IsImplicit(),
 				IsSubCall: isSubCall,
 				TipSet:    msg.TipSet,
 			}
-			pipe <- NewPackMsg(oneMsg, appIds)
+			payload := OneMessagePayload{
+				OneMessage: oneMsg,
+				MCid:       msg.MCid,
+				Receipt:    traceMsg.Receipt,
+			}
+			payload.DecodedCall, payload.ActorMethod = DecodeByEnv(env)
+			pipe <- NewPackMsg(payload, appIds)
 
 		}).Walk(func(item interface{}, pipe chan<- interface{}) {
 			packMsg, ok := item.(PackMsg)
 			if !ok {
 				return
 			}
-			if err := sub.notify.Notify(packMsg.AppIds, &packMsg.Msg); err != nil {
+			record := messageDelivery(msg, packMsg.Msg.Msg.Cid(), packMsg.Msg.IsSubCall)
+			if _, err := sub.deliver(ctx, msg.TipSet, packMsg.AppIds, &packMsg.Msg, record); err != nil {
 				log.Errorf("[MessageApplied] Notify %s failed: %v", msg.MCid.String(), err)
 			}
 		}).Done()
 	})
 }
 
-func (sub *Subscriber) GetAppIdsSubAll() (appIds []string) {
+// withDeliveryLock submit the delivery to the work pool once among the instances, it's skipped if the key is
+// locked by another one. The lock is released once the delivery is submitted if lockerExpire is 0, otherwise
+// it expires, so that the instances which receive the same delivery later skip it. ts is the tipset whose
+// reverts wait for the delivery, nil if there's none
+func (sub *Subscriber) withDeliveryLock(ctx context.Context, key string, ts *types.TipSet, fn func()) error {
+	sub.wg.Add(1)
+
+	lockerCli := locker.NewRedisLock(ctx, key, sub.opts.lockerExpire)
+	ok, err := lockerCli.Acquire(ctx)
+	if err != nil {
+		sub.wg.Done()
+		return fmt.Errorf("[withDeliveryLock] lock %s failed: %v", key, err)
+	}
+	if !ok {
+		sub.wg.Done()
+		log.Infof("[withDeliveryLock] locked %s", key)
+		return nil
+	}
+	if sub.opts.lockerExpire == 0 {
+		defer lockerCli.Release(ctx)
+	}
+
+	done := sub.startWork(ts)
+	err = sub.workPool.Submit(func() {
+		defer sub.wg.Done()
+		defer done()
+		fn()
+	})
+	if err != nil {
+		sub.wg.Done()
+		done()
+	}
+	return err
+}
+
+// startWork start a delivery of the tipset, see tipSetWork
+func (sub *Subscriber) startWork(ts *types.TipSet) (done func()) {
+	if ts == nil {
+		return func() {}
+	}
+	return sub.works.Start(ts.Key())
+}
+
+// WaitTipSet wait for the deliveries of the tipset in progress
+func (sub *Subscriber) WaitTipSet(tsk types.TipSetKey) {
+	sub.works.Wait(tsk)
+}
+
+// SetConfirmations the app is notified only after the tipset is confirmations epochs deep, 0 means at once
+func (sub *Subscriber) SetConfirmations(appId string, confirmations int64) {
+	if confirmations <= 0 {
+		sub.appConfirmations.Delete(appId)
+		return
+	}
+	if confirmations > MaxConfirmations {
+		confirmations = MaxConfirmations
+	}
+	sub.appConfirmations.Store(appId, abi.ChainEpoch(confirmations))
+}
+
+// deliver notify the apps at once, except the apps who want the tipset confirmed,
+// their notifications are buffered until ReleaseConfirmed. delivered the apps notified at once.
+// record is journaled with the apps notified, so that they are told if the tipset is reverted
+func (sub *Subscriber) deliver(ctx context.Context, ts *types.TipSet, appIds []string, msg model.NotifyMessage,
+	record *cache.Delivery) (delivered []string, err error) {
+	var (
+		deferred map[abi.ChainEpoch][]string
+	)
+	for _, appId := range appIds {
+		value, ok := sub.appConfirmations.Load(appId)
+		if !ok || ts == nil {
+			delivered = append(delivered, appId)
+			continue
+		}
+		if deferred == nil {
+			deferred = make(map[abi.ChainEpoch][]string)
+		}
+		confirmations := value.(abi.ChainEpoch)
+		deferred[confirmations] = append(deferred[confirmations], appId)
+	}
+	for confirmations, ids := range deferred {
+		if err := sub.unconfirmed.Add(ctx, ts, confirmations, ids, msg, record); err != nil {
+			log.Errorf("[deliver] hold the notification of %v err:%v", ts.Key(), err)
+		}
+	}
+	if len(delivered) == 0 {
+		return
+	}
+	err = sub.notify.Notify(delivered, msg)
+	if ts != nil {
+		sub.journal(ctx, ts.Height(), ts.Key(), record, delivered)
+	}
+	return delivered, err
+}
+
+// ReleaseConfirmed notify the apps of the buffered notifications which are confirmed by the head
+func (sub *Subscriber) ReleaseConfirmed(ctx context.Context, head abi.ChainEpoch) {
+	for _, confirmed := range sub.unconfirmed.Release(ctx, head) {
+		if err := sub.notify.Notify(confirmed.AppIds, rawMessage(confirmed.Payload)); err != nil {
+			log.Errorf("[ReleaseConfirmed] Notify at %d failed: %v", head, err)
+		}
+		sub.journal(ctx, confirmed.Height, confirmed.TipSet, confirmed.Delivery, confirmed.AppIds)
+	}
+}
+
+// DropUnconfirmed drop the buffered notifications of the reverted tipset, the apps never get them
+func (sub *Subscriber) DropUnconfirmed(ctx context.Context, tsk types.TipSetKey) {
+	if dropped := sub.unconfirmed.Drop(ctx, tsk); dropped > 0 {
+		log.Infof("[DropUnconfirmed] %d notifications of %v are dropped", dropped, tsk)
+	}
+}
+
+// LoadUnconfirmed load the notifications held before the restart, they're released as the head advances
+func (sub *Subscriber) LoadUnconfirmed(ctx context.Context) error {
+	loaded, err := sub.unconfirmed.Load(ctx)
+	if err != nil {
+		return err
+	}
+	log.Infof("[LoadUnconfirmed] %d notifications are loaded", loaded)
+	return nil
+}
+
+// messageDelivery the message delivered from the tipset, the implicit messages are not journaled
+// because they are executed again by whichever tipset is applied at the height
+func messageDelivery(msg *model.Message, c cid.Cid, isSubCall bool) *cache.Delivery {
+	if msg.IsImplicit() {
+		return nil
+	}
+	return &cache.Delivery{MCid: msg.MCid, Cid: c, IsSubCall: isSubCall}
+}
+
+// eventDelivery the state change event delivered from the tipset
+func eventDelivery(event *statechange.Event) *cache.Delivery {
+	return &cache.Delivery{Kind: event.Kind, Address: event.Address.String()}
+}
+
+// journal record the delivery of the tipset to the apps, nothing is recorded if record is nil
+func (sub *Subscriber) journal(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey,
+	record *cache.Delivery, appIds []string) {
+	if record == nil || len(appIds) == 0 {
+		return
+	}
+	delivery := *record
+	delivery.AppIds = appIds
+	if err := sub.opts.deliveryJournal.Record(ctx, height, tsk, delivery); err != nil {
+		log.Errorf("[journal] record delivery of %v at %d failed: %v", tsk, height, err)
+	}
+}
+
+// NotifyReverted tell the apps that the messages and the state change events delivered from the tipset
+// are reverted by a reorg
+func (sub *Subscriber) NotifyReverted(ctx context.Context, ts *types.TipSet) error {
+	// the revert waits for the deliveries of the tipset, it's not one of them
+	return sub.withDeliveryLock(ctx, "reverted:"+ts.Key().String(), nil, func() {
+		journal := sub.opts.deliveryJournal
+		deliveries, err := journal.Deliveries(ctx, ts.Height(), ts.Key())
+		if err != nil {
+			log.Errorf("[NotifyReverted] deliveries of %v err:%v", ts.Key(), err)
+			return
+		}
+		for _, delivery := range deliveries {
+			payload := RevertedPayload{
+				Reverted:  true,
+				TipSet:    ts.Key(),
+				Height:    ts.Height(),
+				MCid:      delivery.MCid,
+				Cid:       delivery.Cid,
+				IsSubCall: delivery.IsSubCall,
+				Kind:      delivery.Kind,
+				Address:   delivery.Address,
+			}
+			if err := sub.notify.Notify(delivery.AppIds, &payload); err != nil {
+				log.Errorf("[NotifyReverted] Notify %s failed: %v", delivery.Cid.String(), err)
+			}
+		}
+		// the tipset may be applied again, the messages are recorded again then
+		if err := journal.Forget(ctx, ts.Height(), ts.Key()); err != nil {
+			log.Errorf("[NotifyReverted] forget %v err:%v", ts.Key(), err)
+		}
+	})
+}
+
+// NotifyPending publish the message entering or leaving the mpool to the apps who subscribe the pending messages
+// of the from or the to address, only the methods and the value range of the filter work before it's applied.
+// from and to are the ID addresses if the actors exist
+func (sub *Subscriber) NotifyPending(ctx context.Context, status string, smsg *types.SignedMessage,
+	from, to address.Address) error {
+	appIds, err := sub.GetAppIdsPending(ctx, &smsg.Message, from, to)
+	if err != nil || len(appIds) == 0 {
+		return err
+	}
+	key := status + ":" + smsg.Cid().String()
+	return sub.withDeliveryLock(ctx, key, nil, func() {
+		payload := PendingPayload{Status: status, Cid: smsg.Cid(), Message: smsg.Message}
+		if _, err := sub.deliver(ctx, nil, appIds, &payload, nil); err != nil {
+			log.Errorf("[NotifyPending] Notify %s failed: %v", key, err)
+		}
+	})
+}
+
+// GetAppIdsPending get the appIds who subscribe the pending messages of the from or the to address
+func (sub *Subscriber) GetAppIdsPending(ctx context.Context, msg *types.Message, from, to address.Address) ([]string, error) {
+	markCache := sub.opts.addressMarkCache
+	if !markCache.ExistAddress(ctx, from.String()) && !markCache.ExistAddress(ctx, to.String()) {
+		return nil, nil
+	}
+	list, err := sub.opts.appSubDao.FindByAddresses(ctx, from.String(), to.String())
+	if err != nil {
+		return nil, err
+	}
+	var appIds []string
+	for _, item := range list {
+		if !item.HasKind(model2.PendingKind) {
+			continue
+		}
+		if !item.MatchMethod(msg.Method) || !item.MatchValue(msg.Value) {
+			continue
+		}
+		appIds = mergeAppIds(appIds, []string{item.AppId})
+	}
+	return appIds, nil
+}
+
+// ExistSubHead whether any app subscribes the heads, so that the head is built only when necessary
+func (sub *Subscriber) ExistSubHead() (exist bool) {
+	sub.subHeadAppIds.Range(func(key, value interface{}) bool {
+		exist = true
+		return false
+	})
+	return
+}
+
+// NotifyHead publish the new head to the apps who subscribe the heads
+func (sub *Subscriber) NotifyHead(ctx context.Context, ts *types.TipSet, head *HeadPayload) error {
+	var appIds []string
+	sub.subHeadAppIds.Range(func(key, value interface{}) bool {
+		appIds = append(appIds, key.(string))
+		return true
+	})
+	if len(appIds) == 0 {
+		return nil
+	}
+	return sub.withDeliveryLock(ctx, "head:"+ts.Key().String(), ts, func() {
+		if _, err := sub.deliver(ctx, ts, appIds, head, nil); err != nil {
+			log.Errorf("[NotifyHead] Notify head %d failed: %v", ts.Height(), err)
+		}
+	})
+}
+
+// NotifyStateChange publish the state change events to the apps who subscribe the kinds of the addresses
+func (sub *Subscriber) NotifyStateChange(ctx context.Context, change *statechange.Change) error {
+	return sub.withDeliveryLock(ctx, "state:"+change.TipSet.Key().String(), change.TipSet, func() {
+		sub.notifyEvents(ctx, change)
+		// the subs of the robust addresses which get an ID address are moved to the ID addresses
+		pairs, err := change.NewAddresses(ctx)
+		if err != nil {
+			log.Errorf("[NotifyStateChange] new addresses at %d err:%v", change.Height(), err)
+			return
+		}
+		sub.UpgradeAddresses(ctx, pairs)
+	})
+}
+
+// notifyEvents extract the events of the kinds and publish them to the apps
+func (sub *Subscriber) notifyEvents(ctx context.Context, change *statechange.Change) {
+	subKinds, err := sub.GetAppIdsByKind(ctx)
+	if err != nil {
+		log.Errorf("[NotifyStateChange] find subs of the kinds err:%v", err)
+		return
+	}
+	for kind, addrApps := range subKinds {
+		extractor, ok := statechange.Lookup(kind)
+		if !ok {
+			continue
+		}
+		addrs := make([]address.Address, 0, len(addrApps))
+		for addr := range addrApps {
+			a, err := address.NewFromString(addr)
+			if err != nil {
+				log.Warnf("[NotifyStateChange] parse address %v err:%v", addr, err)
+				continue
+			}
+			addrs = append(addrs, a)
+		}
+		events, err := extractor.Extract(ctx, change, addrs)
+		if err != nil {
+			log.Errorf("[NotifyStateChange] extract %s events at %d err:%v", kind, change.Height(), err)
+			continue
+		}
+		for _, event := range events {
+			var appIds []string
+			for _, target := range event.Targets() {
+				appIds = mergeAppIds(appIds, addrApps[target.String()])
+			}
+			if len(appIds) == 0 {
+				continue
+			}
+			if _, err := sub.deliver(ctx, change.TipSet, appIds, &EventPayload{Event: event},
+				eventDelivery(event)); err != nil {
+				log.Errorf("[NotifyStateChange] Notify %s event of %v failed: %v", kind, event.Address.String(), err)
+			}
+		}
+	}
+}
+
+// UpgradeAddresses move the subs of the robust addresses to the ID addresses they get,
+// the messages and the state changes are matched by the ID addresses once the actors exist
+func (sub *Subscriber) UpgradeAddresses(ctx context.Context, pairs []builtininit.AddressPair) {
+	markCache := sub.opts.addressMarkCache
+	for _, pair := range pairs {
+		from, to := pair.PK.String(), pair.ID.String()
+		if !markCache.ExistAddress(ctx, from) {
+			continue
+		}
+		upgraded, err := sub.opts.appSubDao.UpgradeAddress(ctx, from, to)
+		if err != nil {
+			log.Errorf("[UpgradeAddresses] upgrade %v to %v err:%v", from, to, err)
+			continue
+		}
+		if upgraded == 0 {
+			continue
+		}
+		if !markCache.ExistAddress(ctx, to) {
+			markCache.MarkAddress(ctx, to)
+		}
+		sub.InvalidateSubKinds(ctx)
+		log.Infof("[UpgradeAddresses] %d subs of %v are upgraded to %v", upgraded, from, to)
+	}
+}
+
+// GetAppIdsByKind get the appIds who subscribe the state change kinds, kind -> address -> appIds,
+// they're cached until the subs of the kinds change
+func (sub *Subscriber) GetAppIdsByKind(ctx context.Context) (map[string]map[string][]string, error) {
+	version, err := sub.opts.subKindsVersion.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cached := &sub.kindSubs
+	cached.lock.Lock()
+	defer cached.lock.Unlock()
+	if cached.loaded && cached.version == version {
+		return cached.subKinds, nil
+	}
+
+	list, err := sub.opts.appSubDao.FindByKinds(ctx, statechange.Kinds())
+	if err != nil {
+		return nil, err
+	}
+	subKinds := make(map[string]map[string][]string)
+	for _, item := range list {
+		for _, kind := range item.Kinds {
+			addrApps, ok := subKinds[kind]
+			if !ok {
+				addrApps = make(map[string][]string)
+				subKinds[kind] = addrApps
+			}
+			addrApps[item.Address] = mergeAppIds(addrApps[item.Address], []string{item.AppId})
+		}
+	}
+	cached.loaded, cached.version, cached.subKinds = true, version, subKinds
+	return subKinds, nil
+}
+
+// InvalidateSubKinds the subs of the addresses changed, the cached subs of the kinds are reloaded
+// by the subscribers of every instance
+func (sub *Subscriber) InvalidateSubKinds(ctx context.Context) {
+	if err := sub.opts.subKindsVersion.Bump(ctx); err != nil {
+		log.Errorf("[InvalidateSubKinds] bump the version err:%v", err)
+	}
+}
+
+// GetAppIdsSubAll get the appIds who subscribe all addresses and whose filter matches the message
+func (sub *Subscriber) GetAppIdsSubAll(env *MsgEnv) (appIds []string) {
 	sub.subAllAppIds.Range(func(key, value interface{}) bool {
+		if filter, ok := value.(*model2.SubAllFilter); ok && !sub.matchSubAllFilter(filter, env) {
+			return true
+		}
 		appIds = append(appIds, key.(string))
 		return true
 	})
 	return
 }
 
-func (sub *Subscriber) GetAppIdsByAddress() func(ctx context.Context, from, to string) (appIds []string) {
-	return func(ctx context.Context, from, to string) (appIds []string) {
+func (sub *Subscriber) GetAppIdsByAddress() func(ctx context.Context, env *MsgEnv) (appIds []string) {
+	return func(ctx context.Context, env *MsgEnv) (appIds []string) {
+		fromAddr, toAddr := env.Addresses()
+		from, to := fromAddr.String(), toAddr.String()
 		markCache := sub.opts.addressMarkCache
 		if markCache.ExistAddress(ctx, to) ||
 			markCache.ExistAddress(ctx, from) {
 			list, err := sub.opts.appSubDao.FindByAddresses(context.Background(),
-				[]string{from, to})
+				from, to)
 			if err != nil {
 				log.Errorf("[core.processing]: find by addresses err: %s", err)
 				return
 			}
+			// an app may subscribe both from and to, make sure that it is notified only once
+			appMap := make(map[string]struct{}, len(list))
 			for _, item := range list {
+				if _, ok := appMap[item.AppId]; ok {
+					continue
+				}
+				// the sub may only subscribe the state changes of the address
+				if !item.HasKind(model2.MessageKind) {
+					continue
+				}
+				if !sub.matchSubFilter(&item.SubFilter, env) {
+					continue
+				}
+				appMap[item.AppId] = struct{}{}
 				appIds = append(appIds, item.AppId)
 			}
 		}
@@ -221,6 +702,75 @@ func (sub *Subscriber) GetAppIdsByAddress() func(ctx context.Context, from, to s
 	}
 }
 
+// GetAppIdsByActor get the appIds who subscribe the actor family of the receiver
+func (sub *Subscriber) GetAppIdsByActor(env *MsgEnv) (appIds []string) {
+	if !sub.existSubActor() {
+		return
+	}
+	code, err := env.ActorCode()
+	if err != nil {
+		// the receiver may be created by the message itself, just to log
+		log.Warnf("[GetAppIdsByActor] get actor code of %v err:%v", env.Msg.To.String(), err)
+		return
+	}
+	apps, ok := sub.subActorAppIds.Load(builtin.ActorFamily(builtin.ActorNameByCode(code)))
+	if !ok {
+		return
+	}
+	apps.(*sync.Map).Range(func(key, value interface{}) bool {
+		if filter, ok := value.(*model2.SubAllFilter); ok && !sub.matchSubAllFilter(filter, env) {
+			return true
+		}
+		appIds = append(appIds, key.(string))
+		return true
+	})
+	return
+}
+
+// existSubActor whether any app subscribes an actor family,
+// so that the actor code is loaded only when necessary
+func (sub *Subscriber) existSubActor() (exist bool) {
+	sub.subActorAppIds.Range(func(key, value interface{}) bool {
+		value.(*sync.Map).Range(func(key, value interface{}) bool {
+			exist = true
+			return false
+		})
+		return !exist
+	})
+	return
+}
+
+// mergeAppIds append the appIds of list which are not in appIds
+func mergeAppIds(appIds []string, list []string) []string {
+	if len(list) == 0 {
+		return appIds
+	}
+	appMap := make(map[string]struct{}, len(appIds))
+	for _, appId := range appIds {
+		appMap[appId] = struct{}{}
+	}
+	for _, appId := range list {
+		if _, ok := appMap[appId]; ok {
+			continue
+		}
+		appMap[appId] = struct{}{}
+		appIds = append(appIds, appId)
+	}
+	return appIds
+}
+
+// matchSubFilter check whether the message matches all the conditions of the filter
+func (sub *Subscriber) matchSubFilter(filter *model2.SubFilter, env *MsgEnv) bool {
+	return filter.MatchMethod(env.Msg.Method) &&
+		filter.MatchExitCode(env.Msg.Receipt.ExitCode) &&
+		filter.MatchValue(env.Msg.Value) &&
+		sub.matchExpr(filter.Expr, env)
+}
+
+func (sub *Subscriber) matchSubAllFilter(filter *model2.SubAllFilter, env *MsgEnv) bool {
+	return filter.MatchValue(env.Msg.Value) && sub.matchExpr(filter.Expr, env)
+}
+
 func (sub *Subscriber) Close() {
 	sub.wg.Wait()
 	// wait for all work msgDone
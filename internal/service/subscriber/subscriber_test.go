@@ -9,12 +9,25 @@ import (
 	"testing"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	cbor "github.com/ipfs/go-ipld-cbor"
+
+	"github.com/bitrainforest/pulsar/chain/actors/adt"
+	builtininit "github.com/bitrainforest/pulsar/chain/actors/builtin/init"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/lotus/chain/vm"
 
 	"github.com/bitrainforest/pulsar/internal/service/subscriber/actoraddress"
+	"github.com/bitrainforest/pulsar/internal/service/subscriber/statechange"
 
 	model2 "github.com/bitrainforest/filmeta-hic/model"
 
@@ -32,6 +45,16 @@ var _ dao.UserAppSubDao = &MockUserAppSubDao{}
 
 type MockUserAppSubDao struct {
 	appIds []string
+	// subs if it is not empty, the subs will be returned by FindByAddresses instead of appIds
+	subs []*model.SpecialUserAppSub
+	// kindSubs the subs returned by FindByKinds
+	kindSubs []*model.SpecialUserAppSub
+	// kindFinds counts the calls of FindByKinds if it is not nil
+	kindFinds *int
+	// upgraded the addresses upgraded by UpgradeAddress, from -> to
+	upgraded map[string]string
+	// appSubs the subs returned by GetByAppId
+	appSubs []model.UserAppSub
 }
 
 func (m MockUserAppSubDao) FindByAddress(ctx context.Context, address string) (list []*model.UserAppSub, err error) {
@@ -39,7 +62,10 @@ func (m MockUserAppSubDao) FindByAddress(ctx context.Context, address string) (l
 	panic("implement me")
 }
 
-func (m MockUserAppSubDao) FindByAddresses(ctx context.Context, address []string) (list []*model.SpecialUserAppSub, err error) {
+func (m MockUserAppSubDao) FindByAddresses(ctx context.Context, from, to string) (list []*model.SpecialUserAppSub, err error) {
+	if len(m.subs) > 0 {
+		return m.subs, nil
+	}
 	for i := range m.appIds {
 		list = append(list, &model.SpecialUserAppSub{
 			AppId: m.appIds[i],
@@ -48,12 +74,28 @@ func (m MockUserAppSubDao) FindByAddresses(ctx context.Context, address []string
 	return list, nil
 }
 
+func (m MockUserAppSubDao) FindByKinds(ctx context.Context, kinds []string) (list []*model.SpecialUserAppSub, err error) {
+	if m.kindFinds != nil {
+		*m.kindFinds++
+	}
+	return m.kindSubs, nil
+}
+
 func (m MockUserAppSubDao) Create(ctx context.Context, appWatchModel *model.UserAppSub) (err error) {
 	//TODO implement me
 	panic("implement me")
 }
 
 func (m MockUserAppSubDao) GetByAppId(ctx context.Context, appId, address string) (appWatchModel model.UserAppSub, err error) {
+	for _, appSub := range m.appSubs {
+		if appSub.AppId == appId && appSub.Address == address {
+			return appSub, nil
+		}
+	}
+	return appWatchModel, nil
+}
+
+func (m MockUserAppSubDao) UpdateFilter(ctx context.Context, appId, address string, filter model.SubFilter) (err error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -63,6 +105,18 @@ func (m MockUserAppSubDao) Cancel(ctx context.Context, appId, address string) (e
 	panic("implement me")
 }
 
+func (m MockUserAppSubDao) UpgradeAddress(ctx context.Context, from, to string) (upgraded int64, err error) {
+	for _, sub := range m.kindSubs {
+		if sub.Address == from {
+			upgraded++
+		}
+	}
+	if upgraded > 0 {
+		m.upgraded[from] = to
+	}
+	return upgraded, nil
+}
+
 func GenerateAddress(item string) address.Address {
 	a, _ := address.NewFromString(item) ////nolint:errcheck
 	return a
@@ -88,6 +142,21 @@ var (
 	initAppIds = []string{"wq", "wq2"}
 )
 
+var _ cache.SubKindsVersion = (*MockSubKindsVersion)(nil)
+
+type MockSubKindsVersion struct {
+	version int64
+}
+
+func (m *MockSubKindsVersion) Version(ctx context.Context) (int64, error) {
+	return m.version, nil
+}
+
+func (m *MockSubKindsVersion) Bump(ctx context.Context) error {
+	m.version++
+	return nil
+}
+
 type MockAddressMark struct {
 	markMap map[string]struct{}
 }
@@ -107,8 +176,63 @@ func (m *MockAddressMark) ExistAddress(ctx context.Context, address string) bool
 }
 
 func (m *MockAddressMark) MarkAddress(ctx context.Context, address string) bool {
-	//TODO implement me
-	panic("implement me")
+	if m.markMap == nil {
+		return false
+	}
+	_, ok := m.markMap[address]
+	m.markMap[address] = struct{}{}
+	return !ok
+}
+
+var _ cache.DeliveryJournal = &MockDeliveryJournal{}
+
+// MockDeliveryJournal keeps the deliveries in memory, keyed by the tipset key
+type MockDeliveryJournal struct {
+	lock       sync.Mutex
+	deliveries map[types.TipSetKey][]cache.Delivery
+}
+
+func NewMockDeliveryJournal() *MockDeliveryJournal {
+	return &MockDeliveryJournal{deliveries: make(map[types.TipSetKey][]cache.Delivery)}
+}
+
+func (m *MockDeliveryJournal) Record(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey, delivery cache.Delivery) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.deliveries[tsk] = append(m.deliveries[tsk], delivery)
+	return nil
+}
+
+func (m *MockDeliveryJournal) Deliveries(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) ([]cache.Delivery, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.deliveries[tsk], nil
+}
+
+func (m *MockDeliveryJournal) Forget(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.deliveries, tsk)
+	return nil
+}
+
+var _ Address = &MockActorAddress{}
+
+// MockActorAddress the addresses are not resolved, the codes of the actors are from the codes map
+type MockActorAddress struct {
+	codes map[address.Address]cid.Cid
+}
+
+func (m *MockActorAddress) GetActorAddress(ctx context.Context, next *types.TipSet, a address.Address) (address.Address, error) {
+	return a, nil
+}
+
+func (m *MockActorAddress) GetActorCode(ctx context.Context, next *types.TipSet, a address.Address) (cid.Cid, error) {
+	code, ok := m.codes[a]
+	if !ok {
+		return cid.Undef, errors.New("actor not found")
+	}
+	return code, nil
 }
 
 func TestNewSub(t *testing.T) {
@@ -214,6 +338,37 @@ func TestSubscriber_AppendAppId_AND_RemoveAppID(t *testing.T) {
 
 }
 
+func TestSubscriber_GetAppIdsSubAll(t *testing.T) {
+	sub, err := NewSub([]string{"all"}, &MockNotify{})
+	assert.Nil(t, err)
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "min",
+		SubAllFilter: model.SubAllFilter{ValueRange: model.ValueRange{MinValue: "100"}}})
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "max",
+		SubAllFilter: model.SubAllFilter{ValueRange: model.ValueRange{MaxValue: "100"}}})
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "range",
+		SubAllFilter: model.SubAllFilter{ValueRange: model.ValueRange{MinValue: "10", MaxValue: "20"}}})
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "expr",
+		SubAllFilter: model.SubAllFilter{Expr: "value >= 15 && !is_subcall"}})
+
+	tests := []struct {
+		name  string
+		value int64
+		want  []string
+	}{
+		{name: "GetAppIdsSubAll0", value: 0, want: []string{"all", "max"}},
+		{name: "GetAppIdsSubAll15", value: 15, want: []string{"all", "max", "range", "expr"}},
+		{name: "GetAppIdsSubAll100", value: 100, want: []string{"all", "min", "max", "expr"}},
+		{name: "GetAppIdsSubAll101", value: 101, want: []string{"all", "min", "expr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := TraceMsg{Message: types.Message{Value: abi.NewTokenAmount(tt.value)}}
+			got := sub.GetAppIdsSubAll(NewMsgEnv(context.Background(), sub, msg, nil, false, false))
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
 func TestSubscriber_NoNotify(t *testing.T) {
 	m := &MockNotify{}
 	sub, err := NewSub(initAppIds, m, WithAddress(actoraddress.NewProxyActorAddress()))
@@ -379,6 +534,452 @@ func TestSubscriber_NotifyMixedMode(t *testing.T) {
 	assert.Equal(t, int64(initAppIds+subAddressCount), m.count, "rand count: "+strconv.Itoa(randCount))
 }
 
+func TestSubscriber_NotifyMethodFilter(t *testing.T) {
+	m := &MockNotify{}
+	t111 := GenerateAddress("t0111")
+	markAddressList := map[string]struct{}{
+		t111.String(): {},
+	}
+
+	// the trace contains two msgs for t0111, method 5 and method 6
+	traceMsg := DefaultTrace
+	traceMsg.Subcalls = append(traceMsg.Subcalls, types.ExecutionTrace{
+		Msg: &types.Message{To: GenerateAddress("t0111"), From: GenerateAddress("t0666"), Method: 6, Nonce: 1},
+	})
+
+	subDao := MockUserAppSubDao{subs: []*model.SpecialUserAppSub{
+		// all methods
+		{AppId: "wq", Address: t111.String()},
+		// only method 6
+		{AppId: "wq2", Address: t111.String(), SubFilter: model.SubFilter{Methods: []abi.MethodNum{6}}},
+		// no method matched
+		{AppId: "wq3", Address: t111.String(), SubFilter: model.SubFilter{Methods: []abi.MethodNum{7, 8}}},
+	}}
+
+	sub, err := NewSub([]string{}, m,
+		WithAddress(actoraddress.NewProxyActorAddress()),
+		WithUserAppSubDao(subDao),
+		WithAddressMarkCache(NewMockMockAddressMark(markAddressList)),
+		WithLockerExpire(0))
+	assert.Nil(t, err)
+
+	var (
+		wg sync.WaitGroup
+	)
+	randCount := rand.Intn(10) + 10
+	for i := 0; i < randCount; i++ {
+		wg.Add(1)
+		msg := &model2.Message{
+			MCid:     RandCId(strconv.Itoa(i) + "NotifyMethodFilter"),
+			Msg:      &types.Message{To: GenerateAddress("t0555"), From: GenerateAddress("t0666"), Method: 5, Nonce: 1},
+			Ret:      &vm.ApplyRet{ExecutionTrace: traceMsg},
+			Implicit: true,
+		}
+		go func() {
+			defer wg.Done()
+			err := sub.Notify(context.Background(), msg)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	sub.Close()
+	// wq receives two msgs, wq2 receives one msg
+	assert.Equal(t, int64(3*randCount), m.count, "rand count: "+strconv.Itoa(randCount))
+}
+
+func TestSubscriber_NotifyExprFilter(t *testing.T) {
+	m := &MockNotify{}
+	t111 := GenerateAddress("t0111")
+	markAddressList := map[string]struct{}{
+		t111.String(): {},
+	}
+
+	// the trace contains two msgs for t0111, method 5 and method 6
+	traceMsg := DefaultTrace
+	traceMsg.Subcalls = append(traceMsg.Subcalls, types.ExecutionTrace{
+		Msg: &types.Message{To: GenerateAddress("t0111"), From: GenerateAddress("t0666"), Method: 6, Nonce: 1},
+	})
+
+	subDao := MockUserAppSubDao{subs: []*model.SpecialUserAppSub{
+		// no expression
+		{AppId: "wq", Address: t111.String()},
+		// only method 6
+		{AppId: "wq2", Address: t111.String(), SubFilter: model.SubFilter{Expr: "method == 6 && implicit"}},
+		// no msg matched
+		{AppId: "wq3", Address: t111.String(), SubFilter: model.SubFilter{Expr: "method in [5, 6] && value > 0"}},
+	}}
+
+	sub, err := NewSub([]string{}, m,
+		WithAddress(actoraddress.NewProxyActorAddress()),
+		WithUserAppSubDao(subDao),
+		WithAddressMarkCache(NewMockMockAddressMark(markAddressList)),
+		WithLockerExpire(0))
+	assert.Nil(t, err)
+
+	var (
+		wg sync.WaitGroup
+	)
+	randCount := rand.Intn(10) + 10
+	for i := 0; i < randCount; i++ {
+		wg.Add(1)
+		msg := &model2.Message{
+			MCid:     RandCId(strconv.Itoa(i) + "NotifyExprFilter"),
+			Msg:      &types.Message{To: GenerateAddress("t0555"), From: GenerateAddress("t0666"), Method: 5, Nonce: 1},
+			Ret:      &vm.ApplyRet{ExecutionTrace: traceMsg},
+			Implicit: true,
+		}
+		go func() {
+			defer wg.Done()
+			err := sub.Notify(context.Background(), msg)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	sub.Close()
+	// wq receives two msgs, wq2 receives one msg
+	assert.Equal(t, int64(3*randCount), m.count, "rand count: "+strconv.Itoa(randCount))
+}
+
+func TestSubscriber_CompileExpr(t *testing.T) {
+	sub, err := NewSub(nil, &MockNotify{})
+	assert.Nil(t, err)
+	expr, err := sub.CompileExpr("method == 2")
+	assert.Nil(t, err)
+	cached, err := sub.CompileExpr("method == 2")
+	assert.Nil(t, err)
+	assert.True(t, expr == cached, "the compiled expression should be cached")
+	_, err = sub.CompileExpr("method = 2")
+	assert.NotNil(t, err)
+
+	// the least recently used expressions are evicted
+	for i := 0; i <= MaxCachedExprs; i++ {
+		_, err = sub.CompileExpr(fmt.Sprintf("method == %d", i+3))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, MaxCachedExprs, sub.exprs.Len())
+	assert.False(t, sub.exprs.Contains("method == 2"))
+}
+
+func TestSubscriber_NotifyExitCodeFilter(t *testing.T) {
+	m := &MockNotify{}
+	t111 := GenerateAddress("t0111")
+	markAddressList := map[string]struct{}{
+		t111.String(): {},
+	}
+
+	// the top-level message failed, the subcall succeeded
+	traceMsg := types.ExecutionTrace{
+		Msg:    &types.Message{To: GenerateAddress("t0111"), From: GenerateAddress("t0666"), Method: 5, Nonce: 1},
+		MsgRct: &types.MessageReceipt{ExitCode: exitcode.Ok},
+		Subcalls: []types.ExecutionTrace{
+			{
+				Msg:    &types.Message{To: GenerateAddress("t0222"), From: GenerateAddress("t0111"), Method: 6, Nonce: 1},
+				MsgRct: &types.MessageReceipt{ExitCode: exitcode.Ok},
+			},
+		},
+	}
+
+	subDao := MockUserAppSubDao{subs: []*model.SpecialUserAppSub{
+		{AppId: "wq", Address: t111.String(), SubFilter: model.SubFilter{ExitCodePolicy: int8(model.SuccessExitCode)}},
+		{AppId: "wq2", Address: t111.String(), SubFilter: model.SubFilter{ExitCodePolicy: int8(model.FailureExitCode)}},
+		{AppId: "wq3", Address: t111.String(), SubFilter: model.SubFilter{
+			ExitCodePolicy: int8(model.SpecificExitCode),
+			ExitCodes:      []exitcode.ExitCode{exitcode.SysErrOutOfGas},
+		}},
+	}}
+
+	sub, err := NewSub([]string{}, m,
+		WithAddress(actoraddress.NewProxyActorAddress()),
+		WithUserAppSubDao(subDao),
+		WithAddressMarkCache(NewMockMockAddressMark(markAddressList)),
+		WithLockerExpire(0))
+	assert.Nil(t, err)
+
+	var (
+		wg sync.WaitGroup
+	)
+	randCount := rand.Intn(10) + 10
+	for i := 0; i < randCount; i++ {
+		wg.Add(1)
+		msg := &model2.Message{
+			MCid: RandCId(strconv.Itoa(i) + "NotifyExitCodeFilter"),
+			Msg:  traceMsg.Msg,
+			Ret: &vm.ApplyRet{
+				MessageReceipt: types.MessageReceipt{ExitCode: exitcode.SysErrOutOfGas},
+				ExecutionTrace: traceMsg,
+			},
+			Implicit: true,
+		}
+		go func() {
+			defer wg.Done()
+			err := sub.Notify(context.Background(), msg)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	sub.Close()
+	// wq receives the subcall, wq2 and wq3 receive the top-level message
+	assert.Equal(t, int64(3*randCount), m.count, "rand count: "+strconv.Itoa(randCount))
+}
+
+func TestSubscriber_NotifyActorFamily(t *testing.T) {
+	m := &MockNotify{}
+	// three msgs, the receiver of the second msg is a miner
+	traceMsg := DefaultTrace
+	actorAddress := &MockActorAddress{codes: map[address.Address]cid.Cid{
+		GenerateAddress("t0555"): builtin7.AccountActorCodeID,
+		GenerateAddress("t0111"): builtin7.StorageMinerActorCodeID,
+	}}
+
+	sub, err := NewSub([]string{}, m,
+		WithAddress(actorAddress),
+		WithUserAppSubDao(MockUserAppSubDao{}),
+		WithAddressMarkCache(NewMockMockAddressMark(map[string]struct{}{})),
+		WithLockerExpire(0))
+	assert.Nil(t, err)
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "miner", AllType: int8(model.ActorAllType), ActorFamily: "storageminer"})
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "miner2", AllType: int8(model.ActorAllType), ActorFamily: "storageminer"})
+	sub.AppendSubAll(model.UserAppSubAll{AppId: "multisig", AllType: int8(model.ActorAllType), ActorFamily: "multisig"})
+	sub.RemoveSubAll("miner2", model.ActorAllType, "storageminer")
+
+	var (
+		wg sync.WaitGroup
+	)
+	randCount := rand.Intn(10) + 10
+	for i := 0; i < randCount; i++ {
+		wg.Add(1)
+		msg := &model2.Message{
+			MCid:     RandCId(strconv.Itoa(i) + "NotifyActorFamily"),
+			Msg:      traceMsg.Msg,
+			Ret:      &vm.ApplyRet{ExecutionTrace: traceMsg},
+			Implicit: true,
+		}
+		go func() {
+			defer wg.Done()
+			err := sub.Notify(context.Background(), msg)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	sub.Close()
+	assert.Equal(t, int64(randCount), m.count, "rand count: "+strconv.Itoa(randCount))
+}
+
+func TestSubscriber_NotifyStateChange(t *testing.T) {
+	m := &MockNotify{}
+	// every subscribed address has one event
+	statechange.Register("test_kind", statechange.ExtractorFunc(func(ctx context.Context,
+		change *statechange.Change, addrs []address.Address) (events []*statechange.Event, err error) {
+		for _, addr := range addrs {
+			events = append(events, statechange.NewEvent("test_kind", change, addr, nil))
+		}
+		return
+	}))
+	// one event of t05 routed to both t01000 and t01001
+	statechange.Register("test_route_kind", statechange.ExtractorFunc(func(ctx context.Context,
+		change *statechange.Change, addrs []address.Address) ([]*statechange.Event, error) {
+		event := statechange.NewEvent("test_route_kind", change, GenerateAddress("t05"), nil)
+		event.Routes = []address.Address{GenerateAddress("t01000"), GenerateAddress("t01001")}
+		return []*statechange.Event{event}, nil
+	}))
+	appSubDao := MockUserAppSubDao{kindSubs: []*model.SpecialUserAppSub{
+		{AppId: "app1", Address: "t01000", SubFilter: model.SubFilter{Kinds: []string{"test_kind"}}},
+		{AppId: "app2", Address: "t01000", SubFilter: model.SubFilter{Kinds: []string{"message", "test_kind"}}},
+		{AppId: "app2", Address: "t01001", SubFilter: model.SubFilter{Kinds: []string{"test_kind", "test_route_kind"}}},
+		{AppId: "app4", Address: "t01000", SubFilter: model.SubFilter{Kinds: []string{"test_route_kind"}}},
+		{AppId: "app4", Address: "t01001", SubFilter: model.SubFilter{Kinds: []string{"test_route_kind"}}},
+		// no extractor of the kind
+		{AppId: "app3", Address: "t01002", SubFilter: model.SubFilter{Kinds: []string{"unknown_kind"}}},
+	}}
+	journal := NewMockDeliveryJournal()
+	sub, err := NewSub([]string{}, m, WithUserAppSubDao(appSubDao), WithDeliveryJournal(journal),
+		WithSubKindsVersion(&MockSubKindsVersion{}), WithLockerExpire(0))
+	assert.Nil(t, err)
+
+	subKinds, err := sub.GetAppIdsByKind(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1", "app2"}, subKinds["test_kind"]["t01000"])
+	assert.Equal(t, []string{"app2"}, subKinds["test_kind"]["t01001"])
+
+	change := NewTestChange(t)
+	err = sub.NotifyStateChange(context.Background(), change)
+	assert.Nil(t, err)
+	sub.Close()
+	// test_kind: app1 and app2 of t01000, app2 of t01001; test_route_kind: app2 and app4 once
+	assert.Equal(t, int64(5), m.count)
+
+	// the events are journaled, they're reverted with the tipset
+	deliveries := journal.deliveries[change.TipSet.Key()]
+	assert.Len(t, deliveries, 3)
+	for _, delivery := range deliveries {
+		assert.NotEmpty(t, delivery.Kind)
+		assert.NotEmpty(t, delivery.Address)
+	}
+}
+
+func TestSubscriber_GetAppIdsByKindCached(t *testing.T) {
+	finds := 0
+	appSubDao := MockUserAppSubDao{
+		kindSubs: []*model.SpecialUserAppSub{
+			{AppId: "app1", Address: "t01000", SubFilter: model.SubFilter{Kinds: []string{"sector"}}},
+		},
+		kindFinds: &finds,
+	}
+	sub, err := NewSub([]string{}, &MockNotify{}, WithUserAppSubDao(appSubDao),
+		WithSubKindsVersion(&MockSubKindsVersion{}))
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		subKinds, err := sub.GetAppIdsByKind(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app1"}, subKinds["sector"]["t01000"])
+	}
+	assert.Equal(t, 1, finds)
+
+	// reloaded after the subs change
+	sub.InvalidateSubKinds(context.Background())
+	_, err = sub.GetAppIdsByKind(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, finds)
+}
+
+func TestSubscriber_NotifyReverted(t *testing.T) {
+	m := &MockNotify{}
+	journal := NewMockDeliveryJournal()
+	markAddressList := map[string]struct{}{GenerateAddress("t0111").String(): {}}
+	sub, err := NewSub([]string{"all"}, m,
+		WithAddress(&MockActorAddress{}),
+		WithUserAppSubDao(MockUserAppSubDao{appIds: []string{"app1", "app2"}}),
+		WithAddressMarkCache(NewMockMockAddressMark(markAddressList)),
+		WithDeliveryJournal(journal),
+		WithLockerExpire(0))
+	assert.Nil(t, err)
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	msg := &model2.Message{
+		TipSet: ts,
+		MCid:   RandCId("NotifyReverted"),
+		Msg:    &types.Message{To: GenerateAddress("t0555"), From: GenerateAddress("t0666")},
+		Ret:    &vm.ApplyRet{ExecutionTrace: DefaultTrace},
+	}
+	err = sub.Notify(context.Background(), msg)
+	assert.Nil(t, err)
+	sub.wg.Wait()
+	// "all" gets the message, app1 and app2 get the sub call to t0111
+	assert.Equal(t, int64(3), m.count)
+	deliveries, err := journal.Deliveries(context.Background(), ts.Height(), ts.Key())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(deliveries))
+
+	err = sub.NotifyReverted(context.Background(), ts)
+	assert.Nil(t, err)
+	sub.Close()
+	assert.Equal(t, int64(6), m.count)
+	deliveries, err = journal.Deliveries(context.Background(), ts.Height(), ts.Key())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(deliveries))
+}
+
+func TestSubscriber_NotifyPending(t *testing.T) {
+	m := &MockNotify{}
+	appSubDao := MockUserAppSubDao{subs: []*model.SpecialUserAppSub{
+		{AppId: "app1", Address: "t01000", SubFilter: model.SubFilter{Kinds: []string{"pending"}}},
+		{AppId: "app2", Address: "t01000", SubFilter: model.SubFilter{Kinds: []string{"message", "pending"}}},
+		// only the messages
+		{AppId: "app3", Address: "t01000"},
+		{AppId: "app4", Address: "t01000",
+			SubFilter: model.SubFilter{Kinds: []string{"pending"}, Methods: []abi.MethodNum{2}}},
+		// app1 subscribes both the from and the to
+		{AppId: "app1", Address: "t01001", SubFilter: model.SubFilter{Kinds: []string{"pending"}}},
+	}}
+	markAddressList := map[string]struct{}{"t01000": {}}
+	sub, err := NewSub([]string{"all"}, m,
+		WithUserAppSubDao(appSubDao),
+		WithAddressMarkCache(NewMockMockAddressMark(markAddressList)),
+		WithLockerExpire(0))
+	assert.Nil(t, err)
+
+	from, to := GenerateAddress("t01000"), GenerateAddress("t01001")
+	smsg := &types.SignedMessage{Message: types.Message{From: from, To: to, Method: 0, Value: abi.NewTokenAmount(1)}}
+	appIds, err := sub.GetAppIdsPending(context.Background(), &smsg.Message, from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1", "app2"}, appIds)
+
+	err = sub.NotifyPending(context.Background(), PendingStatus, smsg, from, to)
+	assert.Nil(t, err)
+	err = sub.NotifyPending(context.Background(), RemovedStatus, smsg, from, to)
+	assert.Nil(t, err)
+	// the messages of the unmarked addresses are not looked up
+	err = sub.NotifyPending(context.Background(), PendingStatus, smsg, to, to)
+	assert.Nil(t, err)
+	sub.Close()
+	// the apps who subscribe all addresses do not get the pending messages
+	assert.Equal(t, int64(4), m.count)
+}
+
+// NewTestChange a change of two empty states
+func NewTestChange(t *testing.T) *statechange.Change {
+	ctx := context.Background()
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewMemory()))
+	tree, err := state.NewStateTree(store, types.StateTreeVersion4)
+	assert.Nil(t, err)
+	root, err := tree.Flush(ctx)
+	assert.Nil(t, err)
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.ParentStateRoot = root
+	ts := mock.TipSet(blk)
+	change, err := statechange.NewChange(store, ts, ts)
+	assert.Nil(t, err)
+	return change
+}
+
+func TestSubscriber_UpgradeAddresses(t *testing.T) {
+	robust, err := address.NewActorAddress([]byte("robust"))
+	assert.Nil(t, err)
+	unmarked, err := address.NewActorAddress([]byte("unmarked"))
+	assert.Nil(t, err)
+	appSubDao := MockUserAppSubDao{
+		kindSubs: []*model.SpecialUserAppSub{{AppId: "app1", Address: robust.String()}},
+		upgraded: map[string]string{},
+	}
+	markCache := NewMockMockAddressMark(map[string]struct{}{robust.String(): {}})
+	version := &MockSubKindsVersion{}
+	sub, err := NewSub([]string{}, &MockNotify{}, WithUserAppSubDao(appSubDao), WithAddressMarkCache(markCache),
+		WithSubKindsVersion(version))
+	assert.Nil(t, err)
+
+	sub.UpgradeAddresses(context.Background(), []builtininit.AddressPair{
+		{ID: GenerateAddress("t01001"), PK: robust},
+		{ID: GenerateAddress("t01002"), PK: unmarked},
+	})
+	assert.Equal(t, map[string]string{robust.String(): "t01001"}, appSubDao.upgraded)
+	assert.True(t, markCache.ExistAddress(context.Background(), "t01001"))
+	assert.False(t, markCache.ExistAddress(context.Background(), "t01002"))
+	// the cached subs of the kinds are stale
+	assert.Equal(t, int64(1), version.version)
+}
+
+func TestSubscriber_ReplayFilters(t *testing.T) {
+	addr := GenerateAddress("t0123")
+	appSubDao := MockUserAppSubDao{appSubs: []model.UserAppSub{
+		{AppId: "app1", Address: addr.String(), SubFilter: model.SubFilter{Direction: int8(model.InboundDirection)}},
+	}}
+	sub, err := NewSub([]string{}, &MockNotify{}, WithUserAppSubDao(appSubDao))
+	assert.Nil(t, err)
+
+	filters, err := sub.replayFilters(context.Background(), ReplayParams{
+		AppId: "app1", Addresses: []address.Address{addr},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int8(model.InboundDirection), filters[addr.String()].Direction)
+
+	// the address not subscribed by the app is never replayed
+	_, err = sub.replayFilters(context.Background(), ReplayParams{
+		AppId: "app2", Addresses: []address.Address{addr},
+	})
+	assert.ErrorIs(t, err, ErrNotSubscribed)
+}
+
 func BenchmarkNotify(b *testing.B) {
 	notify, err := NewNotify(nats.DefaultURL)
 	assert.Nil(b, err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bitrainforest/pulsar/internal/cache"
 	"github.com/bitrainforest/pulsar/internal/dao"
+	"github.com/filecoin-project/lotus/chain/store"
 )
 
 const (
@@ -14,6 +15,8 @@ const (
 	MaxWorkPoolNum     = 3000
 	DefaultLockExpire  = 20   // seconds
 	MaxLockExpire      = 7200 //one day
+	// MaxCachedExprs how many compiled filter expressions are cached, the least recently used are evicted
+	MaxCachedExprs = 1024
 )
 
 type (
@@ -24,6 +27,16 @@ type (
 		addressMarkCache cache.AddressMark
 		actorAddress     Address
 		lockerExpire     uint32
+		// deliveryJournal records the delivered messages to tell the apps when the tipsets are reverted
+		deliveryJournal cache.DeliveryJournal
+		// unconfirmedStore keeps the notifications held for the confirmations across the restarts
+		unconfirmedStore cache.UnconfirmedStore
+		// subKindsVersion tells when the cached subs of the state change kinds are stale
+		subKindsVersion cache.SubKindsVersion
+		// replayJobs keeps the progress of the replays running in the background
+		replayJobs cache.ReplayJobs
+		// chainStore the chain of the embedded node, set by the Core, it's walked by the replays
+		chainStore *store.ChainStore
 	}
 )
 
@@ -34,6 +47,10 @@ func defaultOpts() Opts {
 		workPoolNum:      DefaultWorkPoolNum,
 		lockerExpire:     DefaultLockExpire,
 		actorAddress:     actoraddress.NewProxyActorAddress(),
+		deliveryJournal:  cache.NewDeliveryJournal(context.Background()),
+		subKindsVersion:  cache.NewSubKindsVersion(context.Background()),
+		unconfirmedStore: cache.NewUnconfirmedStore(context.Background()),
+		replayJobs:       cache.NewReplayJobs(context.Background()),
 	}
 }
 
@@ -73,3 +90,39 @@ func WithWorkPoolNum(num int64) OptFn {
 		opts.workPoolNum = num
 	}
 }
+
+// WithDeliveryJournal set the journal of the delivered messages
+func WithDeliveryJournal(journal cache.DeliveryJournal) OptFn {
+	return func(opts *Opts) {
+		if journal != nil {
+			opts.deliveryJournal = journal
+		}
+	}
+}
+
+// WithSubKindsVersion set the version of the subs of the state change kinds
+func WithSubKindsVersion(version cache.SubKindsVersion) OptFn {
+	return func(opts *Opts) {
+		if version != nil {
+			opts.subKindsVersion = version
+		}
+	}
+}
+
+// WithUnconfirmedStore set the store of the notifications held for the confirmations
+func WithUnconfirmedStore(store cache.UnconfirmedStore) OptFn {
+	return func(opts *Opts) {
+		if store != nil {
+			opts.unconfirmedStore = store
+		}
+	}
+}
+
+// WithReplayJobs set the store of the replay jobs
+func WithReplayJobs(jobs cache.ReplayJobs) OptFn {
+	return func(opts *Opts) {
+		if jobs != nil {
+			opts.replayJobs = jobs
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package subscriber
+
+import (
+	"sync"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type (
+	// tipSetWork the deliveries of each tipset in progress, the revert of a tipset waits for them,
+	// so that it reads the complete journal and the buffer of the tipset
+	tipSetWork struct {
+		lock  sync.Mutex
+		works map[types.TipSetKey]*pendingWork
+	}
+
+	pendingWork struct {
+		count int
+		// done closed when the count drops to zero
+		done chan struct{}
+	}
+)
+
+func newTipSetWork() *tipSetWork {
+	return &tipSetWork{works: make(map[types.TipSetKey]*pendingWork)}
+}
+
+// Start a work of the tipset, the returned func must be called when it finishes, it can be called many times
+func (w *tipSetWork) Start(tsk types.TipSetKey) func() {
+	w.lock.Lock()
+	work, ok := w.works[tsk]
+	if !ok {
+		work = &pendingWork{done: make(chan struct{})}
+		w.works[tsk] = work
+	}
+	work.count++
+	w.lock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.lock.Lock()
+			defer w.lock.Unlock()
+			work.count--
+			if work.count == 0 {
+				close(work.done)
+				delete(w.works, tsk)
+			}
+		})
+	}
+}
+
+// Wait for the works of the tipset started before
+func (w *tipSetWork) Wait(tsk types.TipSetKey) {
+	w.lock.Lock()
+	work, ok := w.works[tsk]
+	w.lock.Unlock()
+	if ok {
+		<-work.done
+	}
+}
@@ -0,0 +1,40 @@
+package subscriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTipSetWork(t *testing.T) {
+	works := newTipSetWork()
+	tsk := mock.TipSet(mock.MkBlock(nil, 1, 1)).Key()
+	// no work of the tipset
+	works.Wait(tsk)
+	works.Wait(types.EmptyTSK)
+
+	done1, done2 := works.Start(tsk), works.Start(tsk)
+	waited := make(chan struct{})
+	go func() {
+		works.Wait(tsk)
+		close(waited)
+	}()
+	done1()
+	// done is idempotent
+	done1()
+	select {
+	case <-waited:
+		t.Fatal("the revert should wait for all the works of the tipset")
+	case <-time.After(50 * time.Millisecond):
+	}
+	done2()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("the revert should go on after the works are done")
+	}
+	assert.Empty(t, works.works)
+}
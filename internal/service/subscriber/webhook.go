@@ -0,0 +1,192 @@
+package subscriber
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bitrainforest/filmeta-hic/model"
+	"github.com/go-resty/resty/v2"
+
+	model2 "github.com/bitrainforest/pulsar/internal/model"
+	"github.com/bitrainforest/pulsar/internal/utils/crypt"
+	"github.com/bitrainforest/pulsar/library/httpclient"
+)
+
+const (
+	// SignatureHeader the hex encoded HMAC-SHA256 of "<timestamp>.<body>" by the signing secret of the app
+	SignatureHeader = "X-Pulsar-Signature"
+	// TimestampHeader the unix seconds when the payload is signed, the receivers should reject the old ones
+	TimestampHeader = "X-Pulsar-Timestamp"
+	AppIdHeader     = "X-Pulsar-App-Id"
+)
+
+var (
+	_ Notify   = (*WebhookNotify)(nil)
+	_ Notify   = (*multiNotify)(nil)
+	_ redriver = (*WebhookNotify)(nil)
+	_ redriver = (*multiNotify)(nil)
+)
+
+var (
+	// Webhook when the service is started, it will be initialized.
+	Webhook *WebhookNotify
+)
+
+type (
+	// WebhookEndpoints the endpoints of an app and the secret to sign the payloads
+	WebhookEndpoints struct {
+		Secret string
+		Urls   []string
+	}
+
+	// WebhookNotify post the notifications to the endpoints registered by the apps,
+	// the apps without endpoints are skipped
+	WebhookNotify struct {
+		client *resty.Client
+		// endpoints appId -> WebhookEndpoints
+		endpoints sync.Map
+		now       func() time.Time
+		opts      NotifyOpts
+		// queues each endpoint has its own queue, the dead endpoints are skipped for the cooldown
+		queues *deliveryQueues
+	}
+
+	// multiNotify notify the apps by all the notifies, e.g. nats and webhook
+	multiNotify []Notify
+)
+
+func NewWebhookNotify(optFns ...NotifyOptFn) *WebhookNotify {
+	Webhook = &WebhookNotify{
+		// the failed posts are retried by the retry policy
+		client: httpclient.NewDefaultHttpClient().SetRetryCount(0),
+		now:    time.Now,
+		opts:   newNotifyOpts(optFns...),
+	}
+	Webhook.queues = newDeliveryQueues(model2.WebhookChannel, &Webhook.opts)
+	return Webhook
+}
+
+// SignWebhook sign the payload posted at the timestamp
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	signed := append([]byte(strconv.FormatInt(timestamp, 10)+"."), body...)
+	return crypt.HmacSHA256(secret, signed)
+}
+
+// SetEndpoints replace the endpoints of the app, no urls means the app has no webhook,
+// the notifications queued for the removed endpoints are still posted
+func (w *WebhookNotify) SetEndpoints(appId string, endpoints WebhookEndpoints) {
+	if len(endpoints.Urls) == 0 {
+		w.endpoints.Delete(appId)
+	} else {
+		w.endpoints.Store(appId, endpoints)
+	}
+	kept := make(map[string]bool, len(endpoints.Urls))
+	for _, url := range endpoints.Urls {
+		kept[url] = true
+	}
+	w.queues.remove(appId, kept)
+}
+
+// Notify queue the notification for each endpoint of the apps, it never waits for the posts
+func (w *WebhookNotify) Notify(appIds []string, msg model.NotifyMessage) error {
+	var (
+		msgByte []byte
+	)
+	for _, appId := range appIds {
+		val, ok := w.endpoints.Load(appId)
+		if !ok {
+			continue
+		}
+		if msgByte == nil {
+			var err error
+			if msgByte, err = msg.Get(); err != nil {
+				return err
+			}
+		}
+		endpoints := val.(WebhookEndpoints)
+		for _, url := range endpoints.Urls {
+			to, url, body := appId, url, msgByte
+			w.queues.enqueue(deliveryKey{appId: to, url: url}, delivery{body: body, send: func() (int, error) {
+				return w.deliver(url, to, endpoints.Secret, body)
+			}})
+		}
+	}
+	return nil
+}
+
+// Redrive post the dead letter again with the notifications skipped after it,
+// the endpoint must be still registered by the app
+func (w *WebhookNotify) Redrive(letter *model2.DeadLetter) error {
+	if letter.Channel != model2.WebhookChannel {
+		return errOtherChannel
+	}
+	val, ok := w.endpoints.Load(letter.AppId)
+	if !ok {
+		return fmt.Errorf("the webhook %v is not registered", letter.Url)
+	}
+	endpoints := val.(WebhookEndpoints)
+	for _, url := range endpoints.Urls {
+		if url != letter.Url {
+			continue
+		}
+		for _, payload := range append([]string{letter.Payload}, letter.Payloads...) {
+			if _, err := w.deliver(url, letter.AppId, endpoints.Secret, []byte(payload)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("the webhook %v is not registered", letter.Url)
+}
+
+// deliver post the body with the retries, each attempt is signed with its own timestamp
+func (w *WebhookNotify) deliver(url, appId, secret string, body []byte) (int, error) {
+	return w.opts.retry.Do(func() error {
+		timestamp := w.now().Unix()
+		return w.post(url, appId, timestamp, SignWebhook(secret, timestamp, body), body)
+	})
+}
+
+func (w *WebhookNotify) post(url, appId string, timestamp int64, signature string, body []byte) error {
+	resp, err := w.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(AppIdHeader, appId).
+		SetHeader(TimestampHeader, strconv.FormatInt(timestamp, 10)).
+		SetHeader(SignatureHeader, signature).
+		SetBody(body).Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// Close wait for the queued notifications to be posted
+func (w *WebhookNotify) Close() {
+	w.queues.Close()
+}
+
+// NewMultiNotify notify the apps by each of the notifies in order
+func NewMultiNotify(notifies ...Notify) Notify {
+	return multiNotify(notifies)
+}
+
+// Notify a failed notify does not stop the others, the first error is returned
+func (m multiNotify) Notify(appIds []string, msg model.NotifyMessage) (err error) {
+	for _, n := range m {
+		if nErr := n.Notify(appIds, msg); nErr != nil && err == nil {
+			err = nErr
+		}
+	}
+	return
+}
+
+func (m multiNotify) Close() {
+	for _, n := range m {
+		n.Close()
+	}
+}
@@ -0,0 +1,203 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/bitrainforest/pulsar/internal/dao"
+	"github.com/bitrainforest/pulsar/internal/model"
+)
+
+var _ dao.DeadLetterDao = (*MockDeadLetterDao)(nil)
+
+type MockDeadLetterDao struct {
+	lock    sync.Mutex
+	letters []*model.DeadLetter
+}
+
+func (m *MockDeadLetterDao) Create(ctx context.Context, letter *model.DeadLetter) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	letter.Id = primitive.NewObjectID()
+	stored := *letter
+	m.letters = append(m.letters, &stored)
+	return nil
+}
+
+func (m *MockDeadLetterDao) GetById(ctx context.Context, appId, id string) (model.DeadLetter, error) {
+	return model.DeadLetter{}, nil
+}
+
+func (m *MockDeadLetterDao) ListByAppId(ctx context.Context, appId string,
+	skip, limit int64) ([]*model.DeadLetter, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *MockDeadLetterDao) UpdateErr(ctx context.Context, appId, id, errMsg string) error {
+	return nil
+}
+
+func (m *MockDeadLetterDao) AppendPayload(ctx context.Context, appId, id, payload string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, letter := range m.letters {
+		if letter.Id.Hex() == id {
+			letter.Payloads = append(letter.Payloads, payload)
+		}
+	}
+	return nil
+}
+
+func (m *MockDeadLetterDao) Delete(ctx context.Context, appId, id string) error {
+	return nil
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		received = make(map[string][]string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		assert.Nil(t, err)
+		appId := r.Header.Get(AppIdHeader)
+		secret := "secret_" + appId
+		assert.Equal(t, SignWebhook(secret, timestamp, body), r.Header.Get(SignatureHeader))
+
+		lock.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], appId)
+		lock.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookNotify()
+	webhook.now = func() time.Time { return time.Unix(1660000000, 0) }
+	webhook.SetEndpoints("app1", WebhookEndpoints{
+		Secret: "secret_app1",
+		Urls:   []string{server.URL + "/a", server.URL + "/b"},
+	})
+	webhook.SetEndpoints("app2", WebhookEndpoints{Secret: "secret_app2", Urls: []string{server.URL + "/a"}})
+	// app3 has removed all its webhooks
+	webhook.SetEndpoints("app3", WebhookEndpoints{Secret: "secret_app3", Urls: []string{server.URL + "/c"}})
+	webhook.SetEndpoints("app3", WebhookEndpoints{Secret: "secret_app3"})
+
+	err := webhook.Notify([]string{"app1", "app2", "app3", "app4"}, &HeadPayload{Height: 100})
+	assert.Nil(t, err)
+	// wait for the queued posts
+	webhook.Close()
+	assert.ElementsMatch(t, []string{"app1", "app2"}, received["/a"])
+	assert.Equal(t, []string{"app1"}, received["/b"])
+	assert.Empty(t, received["/c"])
+}
+
+func TestWebhookNotify_DeadLetter(t *testing.T) {
+	var flakyCalls, downCalls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			// recovered after the first 2 attempts
+			if atomic.AddInt64(&flakyCalls, 1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/down":
+			atomic.AddInt64(&downCalls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	deadLetter := &MockDeadLetterDao{}
+	webhook := NewWebhookNotify(
+		WithRetryPolicy(RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}),
+		WithDeadLetterDao(deadLetter))
+	webhook.SetEndpoints("app1", WebhookEndpoints{
+		Secret: "secret_app1",
+		Urls:   []string{server.URL + "/flaky", server.URL + "/down"},
+	})
+
+	err := webhook.Notify([]string{"app1"}, rawMessage(`{"Height":100}`))
+	assert.Nil(t, err)
+	webhook.Close()
+	assert.Equal(t, int64(3), atomic.LoadInt64(&flakyCalls))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&downCalls))
+	if assert.Len(t, deadLetter.letters, 1) {
+		letter := deadLetter.letters[0]
+		assert.Equal(t, "app1", letter.AppId)
+		assert.Equal(t, model.WebhookChannel, letter.Channel)
+		assert.Equal(t, server.URL+"/down", letter.Url)
+		assert.Equal(t, 3, letter.Attempts)
+		assert.JSONEq(t, `{"Height":100}`, letter.Payload)
+		assert.NotEmpty(t, letter.Err)
+
+		// the endpoint is still down
+		assert.NotNil(t, webhook.Redrive(letter))
+		assert.Equal(t, errOtherChannel, webhook.Redrive(&model.DeadLetter{Channel: model.NatsChannel}))
+	}
+}
+
+func TestWebhookNotify_EndpointDown(t *testing.T) {
+	var downCalls int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		} else {
+			atomic.AddInt64(&downCalls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	deadLetter := &MockDeadLetterDao{}
+	webhook := NewWebhookNotify(
+		WithRetryPolicy(RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}),
+		WithDeadLetterDao(deadLetter), WithEndpointCooldown(time.Hour))
+	webhook.SetEndpoints("app1", WebhookEndpoints{Secret: "secret_app1", Urls: []string{server.URL + "/down"}})
+	webhook.SetEndpoints("app2", WebhookEndpoints{Secret: "secret_app2", Urls: []string{server.URL + "/slow"}})
+
+	// the slow endpoint never delays the notify
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, webhook.Notify([]string{"app1", "app2"}, rawMessage(fmt.Sprintf(`{"Height":%d}`, 100+i))))
+	}
+	close(release)
+	webhook.Close()
+
+	// the endpoint is skipped after the first notification failed all the retries,
+	// the skipped ones share its dead letter
+	assert.Equal(t, int64(3), atomic.LoadInt64(&downCalls))
+	if assert.Len(t, deadLetter.letters, 1) {
+		letter := deadLetter.letters[0]
+		assert.JSONEq(t, `{"Height":100}`, letter.Payload)
+		if assert.Len(t, letter.Payloads, 2) {
+			assert.JSONEq(t, `{"Height":102}`, letter.Payloads[1])
+		}
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"Height":100}`)
+	signature := SignWebhook("secret", 1660000000, body)
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, SignWebhook("secret", 1660000000, body))
+	assert.NotEqual(t, signature, SignWebhook("secret", 1660000001, body))
+	assert.NotEqual(t, signature, SignWebhook("other", 1660000000, body))
+}
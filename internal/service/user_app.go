@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/bitrainforest/pulsar/internal/service/subscriber"
 
@@ -25,23 +26,50 @@ type UserAppService interface {
 		appId string, address string) (model.UserAppSub, error)
 	GetAppByAppId(ctx context.Context,
 		appId string) (model.UserApp, error)
+	UpdateSubFilter(ctx context.Context, appId, address string, filter model.SubFilter) error
 	CancelSubAddress(ctx context.Context, appId, address string) error
-	GetWatchAllByAppId(ctx context.Context,
-		appId string) (model.UserAppSubAll, error)
-	CancelAll(ctx context.Context, appId string) error
+	GetWatchAllByAppId(ctx context.Context, appId string,
+		allType model.AllType, actorFamily string) (model.UserAppSubAll, error)
+	CancelAll(ctx context.Context, appId string,
+		allType model.AllType, actorFamily string) error
 	CreateSubAll(ctx context.Context,
 		watchAll model.UserAppSubAll) error
+	UpdateSubAllFilter(ctx context.Context,
+		watchAll model.UserAppSubAll) error
+	UpdateConfirmations(ctx context.Context, appId string, confirmations int64) error
+	ListWebhooks(ctx context.Context, appId string) ([]*model.UserAppWebhook, error)
+	// AddWebhook register the endpoint of the app, return the signing secret of the app
+	AddWebhook(ctx context.Context, appId, url string) (string, error)
+	CancelWebhook(ctx context.Context, appId, url string) error
+	ListDeadLetters(ctx context.Context, appId string,
+		skip, limit int64) ([]*model.DeadLetter, int64, error)
+	GetDeadLetter(ctx context.Context, appId, id string) (model.DeadLetter, error)
+	// RedriveDeadLetter deliver the dead letter again, it's removed once delivered
+	RedriveDeadLetter(ctx context.Context, letter model.DeadLetter) error
+	// ReadStream the notifications of the app after the cursor, wait for the block duration if there is none
+	ReadStream(ctx context.Context, appId, cursor string,
+		count int64, block time.Duration) ([]cache.StreamEvent, error)
+	LastStreamCursor(ctx context.Context, appId string) (string, error)
+	// TouchStream mark the app has a streaming client, its notifications are appended to the stream after
+	TouchStream(ctx context.Context, appId string) error
+	// StreamGap whether the notifications after the cursor are trimmed from the stream,
+	// first the cursor of the oldest notification kept
+	StreamGap(ctx context.Context, appId, cursor string) (first string, gap bool, err error)
 }
 
 type UserAppServiceImpl struct {
-	userApp   dao.UserAppDao
-	appSub    dao.UserAppSubDao
-	appSubAll dao.UserAppSubAllDao
+	userApp    dao.UserAppDao
+	appSub     dao.UserAppSubDao
+	appSubAll  dao.UserAppSubAllDao
+	appWebhook dao.UserAppWebhookDao
+	deadLetter dao.DeadLetterDao
 }
 
 func NewUserAppService(userApp dao.UserAppDao,
-	appWatch dao.UserAppSubDao, appSubAll dao.UserAppSubAllDao) UserAppService {
-	return UserAppServiceImpl{userApp: userApp, appSub: appWatch, appSubAll: appSubAll}
+	appWatch dao.UserAppSubDao, appSubAll dao.UserAppSubAllDao, appWebhook dao.UserAppWebhookDao,
+	deadLetter dao.DeadLetterDao) UserAppService {
+	return UserAppServiceImpl{userApp: userApp, appSub: appWatch, appSubAll: appSubAll,
+		appWebhook: appWebhook, deadLetter: deadLetter}
 }
 
 func (userApp UserAppServiceImpl) CreateUserApp(ctx context.Context,
@@ -59,6 +87,114 @@ func (userApp UserAppServiceImpl) GetAppByAppId(ctx context.Context,
 	return userApp.userApp.GetByAppId(ctx, appId)
 }
 
+func (userApp UserAppServiceImpl) UpdateConfirmations(ctx context.Context,
+	appId string, confirmations int64) error {
+	if err := userApp.userApp.UpdateConfirmations(ctx, appId, confirmations); err != nil {
+		return err
+	}
+	subscriber.Sub.SetConfirmations(appId, confirmations)
+	return nil
+}
+
+func (userApp UserAppServiceImpl) ListWebhooks(ctx context.Context,
+	appId string) ([]*model.UserAppWebhook, error) {
+	return userApp.appWebhook.ListByAppId(ctx, appId)
+}
+
+func (userApp UserAppServiceImpl) AddWebhook(ctx context.Context, appId, url string) (string, error) {
+	app, err := userApp.userApp.GetByAppId(ctx, appId)
+	if err != nil {
+		return "", err
+	}
+	if app.SigningSecret == "" {
+		if err = app.GenSigningSecret(); err != nil {
+			return "", err
+		}
+		if err = userApp.userApp.UpdateSigningSecret(ctx, appId, app.SigningSecret); err != nil {
+			return "", err
+		}
+	}
+	webhook := model.NewDefaultAppWebhook()
+	webhook.AppId = appId
+	webhook.Url = url
+	if err = userApp.appWebhook.Create(ctx, &webhook); err != nil {
+		return "", err
+	}
+	return app.SigningSecret, userApp.reloadWebhooks(ctx, appId, app.SigningSecret)
+}
+
+func (userApp UserAppServiceImpl) CancelWebhook(ctx context.Context, appId, url string) error {
+	if err := userApp.appWebhook.Cancel(ctx, appId, url); err != nil {
+		return err
+	}
+	app, err := userApp.userApp.GetByAppId(ctx, appId)
+	if err != nil {
+		return err
+	}
+	return userApp.reloadWebhooks(ctx, appId, app.SigningSecret)
+}
+
+// reloadWebhooks replace the endpoints of the app which the notifications are posted to
+func (userApp UserAppServiceImpl) reloadWebhooks(ctx context.Context, appId, signingSecret string) error {
+	list, err := userApp.appWebhook.ListByAppId(ctx, appId)
+	if err != nil {
+		return err
+	}
+	endpoints := subscriber.WebhookEndpoints{Secret: signingSecret}
+	for _, v := range list {
+		endpoints.Urls = append(endpoints.Urls, v.Url)
+	}
+	if subscriber.Webhook != nil {
+		subscriber.Webhook.SetEndpoints(appId, endpoints)
+	}
+	return nil
+}
+
+func (userApp UserAppServiceImpl) ListDeadLetters(ctx context.Context, appId string,
+	skip, limit int64) ([]*model.DeadLetter, int64, error) {
+	return userApp.deadLetter.ListByAppId(ctx, appId, skip, limit)
+}
+
+func (userApp UserAppServiceImpl) GetDeadLetter(ctx context.Context, appId, id string) (model.DeadLetter, error) {
+	return userApp.deadLetter.GetById(ctx, appId, id)
+}
+
+func (userApp UserAppServiceImpl) RedriveDeadLetter(ctx context.Context, letter model.DeadLetter) error {
+	id := letter.Id.Hex()
+	if err := subscriber.Sub.Redrive(&letter); err != nil {
+		if updateErr := userApp.deadLetter.UpdateErr(ctx, letter.AppId, id, err.Error()); updateErr != nil {
+			log.Warnf("[RedriveDeadLetter] update %v err:%v", id, updateErr)
+		}
+		return err
+	}
+	return userApp.deadLetter.Delete(ctx, letter.AppId, id)
+}
+
+func (userApp UserAppServiceImpl) ReadStream(ctx context.Context, appId, cursor string,
+	count int64, block time.Duration) ([]cache.StreamEvent, error) {
+	return cache.NewNotifyStream(ctx).Read(ctx, appId, cursor, count, block)
+}
+
+func (userApp UserAppServiceImpl) LastStreamCursor(ctx context.Context, appId string) (string, error) {
+	return cache.NewNotifyStream(ctx).LastCursor(ctx, appId)
+}
+
+func (userApp UserAppServiceImpl) TouchStream(ctx context.Context, appId string) error {
+	return cache.NewNotifyStream(ctx).Touch(ctx, appId)
+}
+
+func (userApp UserAppServiceImpl) StreamGap(ctx context.Context, appId, cursor string) (string, bool, error) {
+	if cursor == cache.StreamBeginning {
+		return "", false, nil
+	}
+	first, err := cache.NewNotifyStream(ctx).FirstCursor(ctx, appId)
+	if err != nil || first == "" {
+		return first, false, err
+	}
+	// the notifications after a cursor older than the first kept may be trimmed
+	return first, cache.CompareCursor(cursor, first) < 0, nil
+}
+
 func (userApp UserAppServiceImpl) FindByAddress(ctx context.Context,
 	address string) ([]*model.UserAppSub, error) {
 	return userApp.appSub.FindByAddress(ctx, address)
@@ -66,18 +202,29 @@ func (userApp UserAppServiceImpl) FindByAddress(ctx context.Context,
 
 func (userApp UserAppServiceImpl) CreateSubAll(ctx context.Context,
 	watchAll model.UserAppSubAll) error {
-	subscriber.Sub.AppendAppId(watchAll.AppId)
+	subscriber.Sub.AppendSubAll(watchAll)
 	return userApp.appSubAll.Create(ctx, &watchAll)
 }
 
-func (userApp UserAppServiceImpl) CancelAll(ctx context.Context, appId string) error {
-	subscriber.Sub.RemoveAppId(appId)
-	return userApp.appSubAll.Cancel(ctx, appId)
+func (userApp UserAppServiceImpl) UpdateSubAllFilter(ctx context.Context,
+	watchAll model.UserAppSubAll) error {
+	if err := userApp.appSubAll.UpdateFilter(ctx, watchAll.AppId, model.AllType(watchAll.AllType),
+		watchAll.ActorFamily, watchAll.SubAllFilter); err != nil {
+		return err
+	}
+	subscriber.Sub.AppendSubAll(watchAll)
+	return nil
+}
+
+func (userApp UserAppServiceImpl) CancelAll(ctx context.Context, appId string,
+	allType model.AllType, actorFamily string) error {
+	subscriber.Sub.RemoveSubAll(appId, allType, actorFamily)
+	return userApp.appSubAll.Cancel(ctx, appId, allType, actorFamily)
 }
 
-func (userApp UserAppServiceImpl) GetWatchAllByAppId(ctx context.Context,
-	appId string) (model.UserAppSubAll, error) {
-	return userApp.appSubAll.GetByAppId(ctx, appId)
+func (userApp UserAppServiceImpl) GetWatchAllByAppId(ctx context.Context, appId string,
+	allType model.AllType, actorFamily string) (model.UserAppSubAll, error) {
+	return userApp.appSubAll.GetByAppId(ctx, appId, allType, actorFamily)
 }
 
 func (userApp UserAppServiceImpl) AddSubAddress(ctx context.Context, appWatch model.UserAppSub) error {
@@ -90,11 +237,25 @@ func (userApp UserAppServiceImpl) AddSubAddress(ctx context.Context, appWatch mo
 			log.Warnf("[AddSubAddress] mark address %v err", appWatch)
 		}
 	}
+	subscriber.Sub.InvalidateSubKinds(ctx)
+	return nil
+}
+
+func (userApp UserAppServiceImpl) UpdateSubFilter(ctx context.Context, appId, address string,
+	filter model.SubFilter) error {
+	if err := userApp.appSub.UpdateFilter(ctx, appId, address, filter); err != nil {
+		return err
+	}
+	subscriber.Sub.InvalidateSubKinds(ctx)
 	return nil
 }
 
 func (userApp UserAppServiceImpl) CancelSubAddress(ctx context.Context, appId, address string) error {
-	return userApp.appSub.Cancel(ctx, appId, address)
+	if err := userApp.appSub.Cancel(ctx, appId, address); err != nil {
+		return err
+	}
+	subscriber.Sub.InvalidateSubKinds(ctx)
+	return nil
 }
 
 func (userApp UserAppServiceImpl) GetAppWatchByAppId(ctx context.Context,
@@ -1,8 +1,11 @@
 package crypt
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	crand "crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"hash/crc32"
 	"math/rand"
@@ -26,6 +29,22 @@ func SHA1(str string) string {
 	return hex.EncodeToString(c.Sum(nil))
 }
 
+// HmacSHA256 the hex encoded HMAC-SHA256 of the data with the key
+func HmacSHA256(key string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RandHex generate n random bytes by the crypto/rand, hex encoded
+func RandHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
 func CRC32(str string) uint32 {
 	return crc32.ChecksumIEEE([]byte(str))
 }
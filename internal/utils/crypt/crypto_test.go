@@ -62,3 +62,38 @@ func TestSHA1(t *testing.T) {
 		})
 	}
 }
+
+func TestHmacSHA256(t *testing.T) {
+	type args struct {
+		key  string
+		data string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"1th", args{"key", "The quick brown fox jumps over the lazy dog"},
+			"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HmacSHA256(tt.args.key, []byte(tt.args.data)); got != tt.want {
+				t.Errorf("HmacSHA256() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandHex(t *testing.T) {
+	got, err := RandHex(32)
+	if err != nil {
+		t.Fatalf("RandHex() error = %v", err)
+	}
+	if len(got) != 64 {
+		t.Errorf("RandHex() = %v, want 64 hex chars", got)
+	}
+	if other, _ := RandHex(32); other == got {
+		t.Errorf("RandHex() = %v twice", got)
+	}
+}
@@ -18,7 +18,9 @@ func NewServices() Services {
 	userAppDao := dao.NewUserAppDao()
 	userAppSubDao := dao.NewUserAppSubDao()
 	userAppSubAllDao := dao.NewUserAppSubAllDao()
-	userAppService := service.NewUserAppService(userAppDao, userAppSubDao, userAppSubAllDao)
+	userAppWebhookDao := dao.NewUserAppWebhookDao()
+	deadLetterDao := dao.NewDeadLetterDao()
+	userAppService := service.NewUserAppService(userAppDao, userAppSubDao, userAppSubAllDao, userAppWebhookDao, deadLetterDao)
 	services := Services{
 		UserAppService: userAppService,
 	}
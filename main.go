@@ -27,6 +27,7 @@ func main() {
 	app := &cli.App{
 		Commands: []*cli.Command{
 			commands.PulsarCommand,
+			commands.ReplayCommand,
 		},
 	}
 	if err := app.RunContext(ctx, os.Args); err != nil {
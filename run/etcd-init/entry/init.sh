@@ -9,6 +9,6 @@ echo "start put key to ${PROJECT_NAME}"
 etcdctl put /${PROJECT_NAME}/data/mongo/uri "mongodb://mongodb:27017/"
 etcdctl put /${PROJECT_NAME}/data/redis '{"addr":"redis:6379", "db":0, "password":"","userName":""}'
 etcdctl put /${PROJECT_NAME}/data/jwt/secret "test"
-etcdctl put /${PROJECT_NAME}/data/nats '{"addr":"127.0.0.1","port":4222}'
+etcdctl put /${PROJECT_NAME}/data/nats '{"addr":"127.0.0.1","port":4222,"jetstream":{"enable":false,"maxAgeHours":72}}'
 
 echo "finish etcd-init"